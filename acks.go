@@ -0,0 +1,113 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// commitFunc is called whenever the committed position advances.
+type commitFunc func(ctx context.Context, committed opencdc.Position) error
+
+// ackTracker keeps track of records that were read but not yet acknowledged.
+// Records are acknowledged in any order, but the committed position only
+// advances once all records up to and including it were acknowledged, so
+// anything that depends on it is never ahead of what the destination has
+// actually processed.
+type ackTracker struct {
+	mu sync.Mutex
+
+	pending    []*pendingAck
+	byPosition map[string]*pendingAck
+	committed  opencdc.Position
+
+	onCommit []commitFunc
+}
+
+type pendingAck struct {
+	position opencdc.Position
+	acked    bool
+}
+
+func newAckTracker(committed opencdc.Position) *ackTracker {
+	return &ackTracker{
+		byPosition: make(map[string]*pendingAck),
+		committed:  committed,
+	}
+}
+
+// OnCommit registers a function that is called with the new committed
+// position whenever it advances.
+func (t *ackTracker) OnCommit(fn commitFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// Track registers a record position as in flight. Positions need to be
+// tracked in the order in which the records are returned to Conduit.
+func (t *ackTracker) Track(position opencdc.Position) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := &pendingAck{position: position}
+	t.pending = append(t.pending, p)
+	t.byPosition[string(position)] = p
+}
+
+// Ack marks the record with the position as acknowledged and advances the
+// committed position over all contiguous acknowledged records.
+func (t *ackTracker) Ack(ctx context.Context, position opencdc.Position) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.byPosition[string(position)]
+	if !ok {
+		return fmt.Errorf("received ack for unknown position %q", position)
+	}
+	p.acked = true
+
+	advanced := false
+	for len(t.pending) > 0 && t.pending[0].acked {
+		t.committed = t.pending[0].position
+		delete(t.byPosition, string(t.pending[0].position))
+		t.pending[0] = nil
+		t.pending = t.pending[1:]
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+
+	for _, fn := range t.onCommit {
+		if err := fn(ctx, t.committed); err != nil {
+			return fmt.Errorf("failed to commit position %q: %w", t.committed, err)
+		}
+	}
+	return nil
+}
+
+// Committed returns the last position up to which all records were
+// acknowledged.
+func (t *ackTracker) Committed() opencdc.Position {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.committed
+}
+
+// InFlight returns the number of records that were read but not yet
+// acknowledged.
+func (t *ackTracker) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, p := range t.pending {
+		if !p.acked {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package arxiv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+)
+
+func TestAckTracker_OutOfOrder(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var commits []opencdc.Position
+	tracker := newAckTracker(opencdc.Position("0"))
+	tracker.OnCommit(func(_ context.Context, committed opencdc.Position) error {
+		commits = append(commits, committed)
+		return nil
+	})
+
+	for _, p := range []string{"1", "2", "3", "4"} {
+		tracker.Track(opencdc.Position(p))
+	}
+	is.Equal(tracker.InFlight(), 4)
+
+	// acking out of order doesn't advance the committed position
+	is.NoErr(tracker.Ack(ctx, opencdc.Position("2")))
+	is.NoErr(tracker.Ack(ctx, opencdc.Position("3")))
+	is.Equal(tracker.Committed(), opencdc.Position("0"))
+	is.Equal(tracker.InFlight(), 2)
+	is.Equal(len(commits), 0)
+
+	// closing the gap commits all contiguous positions at once
+	is.NoErr(tracker.Ack(ctx, opencdc.Position("1")))
+	is.Equal(tracker.Committed(), opencdc.Position("3"))
+	is.Equal(commits, []opencdc.Position{opencdc.Position("3")})
+
+	is.NoErr(tracker.Ack(ctx, opencdc.Position("4")))
+	is.Equal(tracker.Committed(), opencdc.Position("4"))
+	is.Equal(tracker.InFlight(), 0)
+	is.Equal(commits, []opencdc.Position{opencdc.Position("3"), opencdc.Position("4")})
+}
+
+func TestAckTracker_UnknownPosition(t *testing.T) {
+	is := is.New(t)
+
+	tracker := newAckTracker(nil)
+	tracker.Track(opencdc.Position("1"))
+
+	err := tracker.Ack(context.Background(), opencdc.Position("2"))
+	is.True(err != nil)
+	is.Equal(tracker.Committed(), opencdc.Position(nil))
+}
@@ -0,0 +1,96 @@
+package arxivtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const oaiIdentifierPrefix = "oai:arXiv.org:"
+
+// serveOAI implements the GetRecord verb of the OAI-PMH interface with the
+// arXivRaw metadata format.
+func (a *API) serveOAI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+
+	switch {
+	case r.Form.Get("verb") != "GetRecord":
+		_, _ = w.Write(oaiError("badVerb", "Illegal OAI verb"))
+		return
+	case r.Form.Get("metadataPrefix") != "arXivRaw":
+		_, _ = w.Write(oaiError("cannotDisseminateFormat", "Format not supported"))
+		return
+	}
+
+	id := strings.TrimPrefix(r.Form.Get("identifier"), oaiIdentifierPrefix)
+	a.mu.Lock()
+	var paper *Paper
+	for i := range a.papers {
+		if a.papers[i].ID == id {
+			p := a.papers[i]
+			paper = &p
+			break
+		}
+	}
+	a.mu.Unlock()
+
+	if paper == nil {
+		_, _ = w.Write(oaiError("idDoesNotExist", "Value of the identifier argument is unknown or illegal in this repository."))
+		return
+	}
+	_, _ = w.Write(oaiRecord(*paper))
+}
+
+func oaiHeader(w *feedWriter) {
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	w.WriteString(`<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">` + "\n")
+	w.element("  ", "responseDate", time.Now().UTC().Format(timeLayout))
+}
+
+func oaiError(code, msg string) []byte {
+	w := &feedWriter{}
+	oaiHeader(w)
+	w.element("  ", fmt.Sprintf("error code=%q", code), msg)
+	w.WriteString("</OAI-PMH>\n")
+	return w.Bytes()
+}
+
+func oaiRecord(p Paper) []byte {
+	w := &feedWriter{}
+	oaiHeader(w)
+	w.WriteString("  <GetRecord>\n    <record>\n      <header>\n")
+	w.element("        ", "identifier", oaiIdentifierPrefix+p.ID)
+	w.WriteString("      </header>\n      <metadata>\n")
+	w.WriteString(`        <arXivRaw xmlns="http://arxiv.org/OAI/arXivRaw/">` + "\n")
+	w.element("          ", "id", p.ID)
+	for i, v := range p.versions() {
+		fmt.Fprintf(w, "          <version version=\"v%d\">\n", i+1)
+		w.element("            ", "date", v.Submitted.UTC().Format("Mon, 2 Jan 2006 15:04:05 GMT"))
+		if v.Size != "" {
+			w.element("            ", "size", v.Size)
+		}
+		if v.Withdrawn {
+			w.element("            ", "source_type", "I")
+		}
+		w.WriteString("          </version>\n")
+	}
+	w.element("          ", "title", p.Title)
+	w.element("          ", "authors", strings.Join(p.Authors, ", "))
+	w.element("          ", "categories", strings.Join(p.Categories, " "))
+	if p.Comment != "" {
+		w.element("          ", "comments", p.Comment)
+	}
+	if p.JournalRef != "" {
+		w.element("          ", "journal-ref", p.JournalRef)
+	}
+	if p.DOI != "" {
+		w.element("          ", "doi", p.DOI)
+	}
+	if p.License != "" {
+		w.element("          ", "license", p.License)
+	}
+	w.element("          ", "abstract", p.Summary)
+	w.WriteString("        </arXivRaw>\n      </metadata>\n    </record>\n  </GetRecord>\n</OAI-PMH>\n")
+	return w.Bytes()
+}
@@ -0,0 +1,175 @@
+package arxivtest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paper is a paper in the corpus served by the fake API.
+type Paper struct {
+	// ID is the arXiv identifier without version, e.g. 2401.12345 or
+	// hep-th/9901001.
+	ID string
+	// Version is the latest version of the paper, defaults to 1.
+	Version    int
+	Title      string
+	Summary    string
+	Authors    []string
+	Published  time.Time
+	Updated    time.Time
+	Comment    string
+	JournalRef string
+	DOI        string
+	// Categories are the categories of the paper, the first one is the
+	// primary category.
+	Categories []string
+	// Versions is the version history served by the OAI-PMH endpoint. If
+	// empty, version 1 is submitted at Published and later versions at
+	// Updated.
+	Versions []Version
+	// License is the license URL served by the OAI-PMH endpoint.
+	License string
+}
+
+// Version is a single version in the history of a paper.
+type Version struct {
+	Submitted time.Time
+	// Size is the size as reported by arXiv, e.g. 37kb.
+	Size string
+	// Withdrawn marks a version that withdrew the paper.
+	Withdrawn bool
+}
+
+// VersionedID returns the identifier of the latest version of the paper,
+// e.g. 2401.12345v2.
+func (p Paper) VersionedID() string {
+	return p.ID + "v" + strconv.Itoa(p.version())
+}
+
+func (p Paper) version() int {
+	if p.Version < 1 {
+		return 1
+	}
+	return p.Version
+}
+
+func (p Paper) versions() []Version {
+	if len(p.Versions) > 0 {
+		return p.Versions
+	}
+	versions := make([]Version, p.version())
+	for i := range versions {
+		versions[i].Submitted = p.Published
+		if i > 0 {
+			versions[i].Submitted = p.updated()
+		}
+	}
+	return versions
+}
+
+func (p Paper) updated() time.Time {
+	if p.Updated.IsZero() {
+		return p.Published
+	}
+	return p.Updated
+}
+
+type feedXML struct {
+	Entries []entryXML `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type entryXML struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Comment         string `xml:"http://arxiv.org/schemas/atom comment"`
+	JournalRef      string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	DOI             string `xml:"http://arxiv.org/schemas/atom doi"`
+	PrimaryCategory struct {
+		Term string `xml:"term,attr"`
+	} `xml:"http://arxiv.org/schemas/atom primary_category"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+// ParseFeed parses the entries of an Atom feed returned by the arXiv API,
+// so real responses can be used as a corpus.
+func ParseFeed(r io.Reader) ([]Paper, error) {
+	var feed feedXML
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	papers := make([]Paper, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		p, err := e.paper()
+		if err != nil {
+			return nil, err
+		}
+		papers = append(papers, p)
+	}
+	return papers, nil
+}
+
+// LoadFeed reads a corpus from a file containing an Atom feed.
+func LoadFeed(path string) ([]Paper, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feed: %w", err)
+	}
+	defer f.Close()
+	return ParseFeed(f)
+}
+
+func (e entryXML) paper() (Paper, error) {
+	id := e.ID
+	if i := strings.Index(id, "/abs/"); i >= 0 {
+		id = id[i+len("/abs/"):]
+	}
+	version := 1
+	if i := strings.LastIndex(id, "v"); i > 0 {
+		if v, err := strconv.Atoi(id[i+1:]); err == nil {
+			id, version = id[:i], v
+		}
+	}
+
+	p := Paper{
+		ID:         id,
+		Version:    version,
+		Title:      strings.TrimSpace(e.Title),
+		Summary:    strings.TrimSpace(e.Summary),
+		Comment:    e.Comment,
+		JournalRef: e.JournalRef,
+		DOI:        e.DOI,
+	}
+	var err error
+	if p.Published, err = time.Parse(time.RFC3339, e.Published); err != nil {
+		return Paper{}, fmt.Errorf("entry %s: invalid published date: %w", e.ID, err)
+	}
+	if p.Updated, err = time.Parse(time.RFC3339, e.Updated); err != nil {
+		return Paper{}, fmt.Errorf("entry %s: invalid updated date: %w", e.ID, err)
+	}
+	for _, a := range e.Authors {
+		p.Authors = append(p.Authors, a.Name)
+	}
+	if e.PrimaryCategory.Term != "" {
+		p.Categories = append(p.Categories, e.PrimaryCategory.Term)
+	}
+	for _, c := range e.Categories {
+		if c.Term != e.PrimaryCategory.Term {
+			p.Categories = append(p.Categories, c.Term)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,298 @@
+package arxivtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var errInvalidQuery = errors.New("invalid search_query")
+
+// query is a parsed arXiv search query that can be matched against papers.
+type query interface {
+	match(p Paper) bool
+}
+
+type andQuery struct{ left, right query }
+
+func (q andQuery) match(p Paper) bool { return q.left.match(p) && q.right.match(p) }
+
+type orQuery struct{ left, right query }
+
+func (q orQuery) match(p Paper) bool { return q.left.match(p) || q.right.match(p) }
+
+type andNotQuery struct{ left, right query }
+
+func (q andNotQuery) match(p Paper) bool { return q.left.match(p) && !q.right.match(p) }
+
+type matchAll struct{}
+
+func (matchAll) match(Paper) bool { return true }
+
+// termQuery matches a single field, e.g. ti:"neural networks".
+type termQuery struct {
+	field string
+	value string
+	// from and to are set for date range queries.
+	from, to time.Time
+}
+
+func (q termQuery) match(p Paper) bool {
+	switch q.field {
+	case "ti":
+		return containsFold(p.Title, q.value)
+	case "abs":
+		return containsFold(p.Summary, q.value)
+	case "au":
+		for _, a := range p.Authors {
+			if containsFold(a, strings.ReplaceAll(q.value, "_", " ")) {
+				return true
+			}
+		}
+		return false
+	case "co":
+		return containsFold(p.Comment, q.value)
+	case "jr":
+		return containsFold(p.JournalRef, q.value)
+	case "cat":
+		for _, c := range p.Categories {
+			if matchCategory(c, q.value) {
+				return true
+			}
+		}
+		return false
+	case "id":
+		return p.ID == q.value || p.VersionedID() == q.value
+	case "submittedDate":
+		return inRange(p.Published, q.from, q.to)
+	case "lastUpdatedDate":
+		return inRange(p.updated(), q.from, q.to)
+	default: // all
+		if containsFold(p.Title, q.value) || containsFold(p.Summary, q.value) ||
+			containsFold(p.Comment, q.value) || containsFold(p.JournalRef, q.value) {
+			return true
+		}
+		for _, a := range p.Authors {
+			if containsFold(a, q.value) {
+				return true
+			}
+		}
+		for _, c := range p.Categories {
+			if matchCategory(c, q.value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func matchCategory(category, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(category, prefix)
+	}
+	return category == pattern
+}
+
+func inRange(t, from, to time.Time) bool {
+	return !t.Before(from) && !t.After(to)
+}
+
+// parseQuery parses the subset of the arXiv query syntax supported by the
+// fake API: field prefixes (ti, au, abs, co, jr, cat, id, all), quoted
+// phrases, date ranges on submittedDate and lastUpdatedDate, the boolean
+// operators AND, OR and ANDNOT, and parentheses. Terms without an operator in
+// between are combined with AND.
+func parseQuery(s string) (query, error) {
+	if strings.TrimSpace(s) == "" {
+		return matchAll{}, nil
+	}
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", errInvalidQuery, p.tokens[p.pos])
+	}
+	return q, nil
+}
+
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '+':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '+' && s[i] != '(' && s[i] != ')' {
+				switch s[i] {
+				case '"':
+					end := strings.IndexByte(s[i+1:], '"')
+					if end < 0 {
+						return nil, fmt.Errorf("%w: unterminated quote", errInvalidQuery)
+					}
+					i += end + 2
+				case '[':
+					end := strings.IndexByte(s[i+1:], ']')
+					if end < 0 {
+						return nil, fmt.Errorf("%w: unterminated range", errInvalidQuery)
+					}
+					i += end + 2
+				default:
+					i++
+				}
+			}
+			tokens = append(tokens, s[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) parseExpr() (query, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		switch op {
+		case "", ")":
+			return left, nil
+		case "AND", "OR", "ANDNOT":
+			p.pos++
+		default:
+			op = "AND"
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "AND":
+			left = andQuery{left, right}
+		case "OR":
+			left = orQuery{left, right}
+		case "ANDNOT":
+			left = andNotQuery{left, right}
+		}
+	}
+}
+
+func (p *parser) parseOperand() (query, error) {
+	tok := p.peek()
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("%w: unexpected end of query", errInvalidQuery)
+	case "(":
+		p.pos++
+		q, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", errInvalidQuery)
+		}
+		p.pos++
+		return q, nil
+	case ")", "AND", "OR", "ANDNOT":
+		return nil, fmt.Errorf("%w: unexpected %q", errInvalidQuery, tok)
+	}
+	p.pos++
+	return parseTerm(tok)
+}
+
+func parseTerm(tok string) (query, error) {
+	field, value, ok := strings.Cut(tok, ":")
+	if !ok {
+		field, value = "all", tok
+	}
+	value = strings.Trim(value, `"`)
+
+	switch field {
+	case "ti", "au", "abs", "co", "jr", "cat", "id", "all":
+		if value == "" {
+			return nil, fmt.Errorf("%w: empty value for %s", errInvalidQuery, field)
+		}
+		return termQuery{field: field, value: value}, nil
+	case "submittedDate", "lastUpdatedDate":
+		from, to, err := parseDateRange(value)
+		if err != nil {
+			return nil, err
+		}
+		return termQuery{field: field, from: from, to: to}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown field %q", errInvalidQuery, field)
+	}
+}
+
+// parseDateRange parses a range like [202401010000 TO 202401312359].
+func parseDateRange(value string) (time.Time, time.Time, error) {
+	inner, ok := strings.CutPrefix(value, "[")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+	}
+	fromStr, toStr, found := strings.Cut(inner, " TO ")
+	if !ok || !found {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date range %q", errInvalidQuery, value)
+	}
+	from, err := parseQueryDate(strings.TrimSpace(fromStr), false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseQueryDate(strings.TrimSpace(toStr), true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// parseQueryDate parses dates in the formats accepted by arXiv (YYYYMMDD,
+// YYYYMMDDHHMM or YYYYMMDDHHMMSS). If end is true, the date is extended to
+// the end of the period it describes.
+func parseQueryDate(s string, end bool) (time.Time, error) {
+	var layout string
+	var period time.Duration
+	switch len(s) {
+	case 8:
+		layout, period = "20060102", 24*time.Hour
+	case 12:
+		layout, period = "200601021504", time.Minute
+	case 14:
+		layout, period = "20060102150405", time.Second
+	default:
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", errInvalidQuery, s)
+	}
+	t, err := time.ParseInLocation(layout, s, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", errInvalidQuery, s)
+	}
+	if end {
+		t = t.Add(period - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,455 @@
+// Package arxivtest provides an in-memory implementation of the arXiv query
+// API for tests. It serves a fixed corpus of papers and supports paging,
+// sorting, id_list, a subset of the query syntax, opensearch totals, error
+// entries and injectable faults. Version histories are served through the
+// GetRecord verb of the OAI-PMH interface.
+package arxivtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResultCap is the maximum number of results arXiv returns for a
+	// single query, regardless of paging.
+	DefaultResultCap = 30000
+
+	defaultMaxResults = 10
+)
+
+var idPattern = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z\-]+(\.[A-Z]{2})?/\d{7})(v(\d+))?$`)
+
+// Fault is an error condition returned for a single request instead of (or
+// in addition to) the regular response.
+type Fault struct {
+	// Status is the HTTP status returned instead of the regular response,
+	// e.g. http.StatusServiceUnavailable.
+	Status int
+	// Body is the response body returned together with Status.
+	Body string
+	// Empty returns a feed without entries, while still reporting the total
+	// number of results.
+	Empty bool
+	// Delay is how long the server waits before responding.
+	Delay time.Duration
+}
+
+// Option configures an API.
+type Option func(*API)
+
+// WithResultCap sets the maximum number of results returned for a query.
+// Entries beyond the cap are never returned. Defaults to DefaultResultCap.
+func WithResultCap(n int) Option {
+	return func(a *API) { a.resultCap = n }
+}
+
+// API is an in-memory arXiv query API, usable as an http.Handler.
+type API struct {
+	mu        sync.Mutex
+	papers    []Paper
+	faults    []Fault
+	requests  []url.Values
+	resultCap int
+}
+
+// NewAPI returns an API serving the given papers. The order of papers is the
+// order results are returned in when sorting by relevance.
+func NewAPI(papers []Paper, opts ...Option) *API {
+	a := &API{
+		papers:    append([]Paper(nil), papers...),
+		resultCap: DefaultResultCap,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Add adds papers to the corpus, or replaces papers with the same ID.
+func (a *API) Add(papers ...Paper) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+next:
+	for _, p := range papers {
+		for i := range a.papers {
+			if a.papers[i].ID == p.ID {
+				a.papers[i] = p
+				continue next
+			}
+		}
+		a.papers = append(a.papers, p)
+	}
+}
+
+// Remove removes the papers with the given IDs from the corpus.
+func (a *API) Remove(ids ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	papers := a.papers[:0]
+	for _, p := range a.papers {
+		if !remove[p.ID] {
+			papers = append(papers, p)
+		}
+	}
+	a.papers = papers
+}
+
+// InjectFaults queues faults that are applied to the next requests (to any
+// endpoint), one fault per request, in order.
+func (a *API) InjectFaults(faults ...Fault) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.faults = append(a.faults, faults...)
+}
+
+// Requests returns the query parameters of all requests received so far.
+func (a *API) Requests() []url.Values {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]url.Values, len(a.requests))
+	for i, r := range a.requests {
+		out[i] = make(url.Values, len(r))
+		for k, v := range r {
+			out[i][k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	a.requests = append(a.requests, r.Form)
+	var fault Fault
+	if len(a.faults) > 0 {
+		fault, a.faults = a.faults[0], a.faults[1:]
+	}
+	a.mu.Unlock()
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault.Status != 0 {
+		w.WriteHeader(fault.Status)
+		fmt.Fprint(w, fault.Body)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/oai2") {
+		a.serveOAI(w, r)
+		return
+	}
+
+	res, err := a.query(r.Form)
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(errorFeed(r.URL, err.Error()))
+		return
+	}
+	if fault.Empty {
+		res.papers = nil
+	}
+	_, _ = w.Write(resultFeed(r.URL, res))
+}
+
+// result is a single page of query results.
+type result struct {
+	papers []Paper
+	total  int
+	start  int
+	limit  int
+}
+
+func (a *API) query(params url.Values) (result, error) {
+	start, err := intParam(params, "start", 0)
+	if err != nil || start < 0 {
+		return result{}, fmt.Errorf("start must be an integer")
+	}
+	limit, err := intParam(params, "max_results", defaultMaxResults)
+	if err != nil || limit < 0 {
+		return result{}, fmt.Errorf("max_results must be an integer")
+	}
+	if limit > a.resultCap {
+		return result{}, fmt.Errorf("max_results must be less than or equal to %d", a.resultCap)
+	}
+
+	q, err := parseQuery(params.Get("search_query"))
+	if err != nil {
+		return result{}, err
+	}
+
+	var ids []string
+	if s := params.Get("id_list"); s != "" {
+		ids = strings.Split(s, ",")
+		for _, id := range ids {
+			if !idPattern.MatchString(id) {
+				return result{}, fmt.Errorf("incorrect id format for %s", id)
+			}
+		}
+	}
+
+	a.mu.Lock()
+	var papers []Paper
+	if ids != nil {
+		// without a search query, papers are returned in id_list order
+		papers = a.lookup(ids)
+	} else {
+		papers = append(papers, a.papers...)
+	}
+	a.mu.Unlock()
+
+	matched := papers[:0]
+	for _, p := range papers {
+		if q.match(p) {
+			matched = append(matched, p)
+		}
+	}
+
+	if err := sortPapers(matched, params.Get("sortBy"), params.Get("sortOrder"), ids != nil); err != nil {
+		return result{}, err
+	}
+
+	if len(matched) > a.resultCap {
+		matched = matched[:a.resultCap]
+	}
+	res := result{total: len(matched), start: start, limit: limit}
+	if start < len(matched) {
+		res.papers = matched[start:min(start+limit, len(matched))]
+	}
+	return res, nil
+}
+
+// lookup returns the papers with the given (optionally versioned) IDs. A
+// versioned ID returns the paper as it was at that version.
+func (a *API) lookup(ids []string) []Paper {
+	var out []Paper
+	for _, id := range ids {
+		m := idPattern.FindStringSubmatch(id)
+		for _, p := range a.papers {
+			if p.ID != m[1] {
+				continue
+			}
+			if m[4] != "" {
+				v, _ := strconv.Atoi(m[4])
+				if v > p.version() {
+					break
+				}
+				p.Version = v
+			}
+			out = append(out, p)
+			break
+		}
+	}
+	return out
+}
+
+func sortPapers(papers []Paper, sortBy, sortOrder string, keepOrder bool) error {
+	switch sortOrder {
+	case "", "descending", "ascending":
+	default:
+		return fmt.Errorf("sortOrder must be in: ascending, descending")
+	}
+	desc := sortOrder != "ascending"
+
+	var less func(a, b Paper) bool
+	switch sortBy {
+	case "":
+		if keepOrder {
+			return nil
+		}
+		fallthrough
+	case "relevance":
+		// the corpus is ordered by relevance
+		if !desc {
+			for i, j := 0, len(papers)-1; i < j; i, j = i+1, j-1 {
+				papers[i], papers[j] = papers[j], papers[i]
+			}
+		}
+		return nil
+	case "submittedDate":
+		less = func(a, b Paper) bool { return a.Published.Before(b.Published) }
+	case "lastUpdatedDate":
+		less = func(a, b Paper) bool { return a.updated().Before(b.updated()) }
+	default:
+		return fmt.Errorf("sortBy must be in: relevance, lastUpdatedDate, submittedDate")
+	}
+
+	sort.SliceStable(papers, func(i, j int) bool {
+		if desc {
+			return less(papers[j], papers[i])
+		}
+		return less(papers[i], papers[j])
+	})
+	return nil
+}
+
+func intParam(params url.Values, name string, def int) (int, error) {
+	s := params.Get(name)
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s) //nolint:wrapcheck // replaced by an API error message
+}
+
+// Server is an httptest.Server serving an API.
+type Server struct {
+	*API
+	srv *httptest.Server
+}
+
+// NewServer starts a server serving the given papers. It must be closed
+// with Close.
+func NewServer(papers []Paper, opts ...Option) *Server {
+	api := NewAPI(papers, opts...)
+	return &Server{
+		API: api,
+		srv: httptest.NewServer(api),
+	}
+}
+
+// URL returns the URL of the query endpoint, to be used as arxiv_api_url.
+func (s *Server) URL() string {
+	return s.srv.URL + "/api/query"
+}
+
+// OAIURL returns the URL of the OAI-PMH endpoint, to be used as
+// version_history.url.
+func (s *Server) OAIURL() string {
+	return s.srv.URL + "/oai2"
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+const (
+	atomNS       = "http://www.w3.org/2005/Atom"
+	arxivNS      = "http://arxiv.org/schemas/atom"
+	opensearchNS = "http://a9.com/-/spec/opensearch/1.1/"
+	timeLayout   = "2006-01-02T15:04:05Z"
+)
+
+// feedWriter writes an Atom feed in the format used by the arXiv API.
+type feedWriter struct {
+	bytes.Buffer
+}
+
+func (w *feedWriter) element(indent, name, value string) {
+	fmt.Fprintf(w, "%s<%s>", indent, name)
+	_ = xml.EscapeText(w, []byte(value))
+	fmt.Fprintf(w, "</%s>\n", strings.Fields(name)[0])
+}
+
+func (w *feedWriter) attr(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func (w *feedWriter) header(u *url.URL, title string, total, start, limit int) {
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(w, "<feed xmlns=%q xmlns:opensearch=%q xmlns:arxiv=%q>\n", atomNS, opensearchNS, arxivNS)
+	fmt.Fprintf(w, "  <link href=\"%s\" rel=\"self\" type=\"application/atom+xml\"/>\n", w.attr("http://arxiv.org/api/query?"+u.RawQuery))
+	w.element("  ", `title type="html"`, title)
+	w.element("  ", "id", "http://arxiv.org/api/"+u.RawQuery)
+	w.element("  ", "updated", time.Now().UTC().Format(timeLayout))
+	w.element("  ", "opensearch:totalResults", strconv.Itoa(total))
+	w.element("  ", "opensearch:startIndex", strconv.Itoa(start))
+	w.element("  ", "opensearch:itemsPerPage", strconv.Itoa(limit))
+}
+
+func resultFeed(u *url.URL, res result) []byte {
+	w := &feedWriter{}
+	w.header(u, "ArXiv Query: "+u.RawQuery, res.total, res.start, res.limit)
+	for _, p := range res.papers {
+		w.entry(p)
+	}
+	w.WriteString("</feed>\n")
+	return w.Bytes()
+}
+
+// errorFeed returns the feed arXiv responds with when a request is invalid:
+// a single entry titled "Error" describing the problem.
+func errorFeed(u *url.URL, msg string) []byte {
+	anchor := strings.NewReplacer(" ", "_", "\"", "").Replace(msg)
+	w := &feedWriter{}
+	w.header(u, "ArXiv Query: "+u.RawQuery, 1, 0, 1)
+	w.WriteString("  <entry>\n")
+	w.element("    ", "id", "http://arxiv.org/api/errors#"+anchor)
+	w.element("    ", "title", "Error")
+	w.element("    ", "summary", msg)
+	w.element("    ", "updated", time.Now().UTC().Format(timeLayout))
+	fmt.Fprintf(w, "    <link href=\"%s\" rel=\"alternate\" type=\"text/html\"/>\n", w.attr("http://arxiv.org/api/errors#"+anchor))
+	w.WriteString("    <author>\n")
+	w.element("      ", "name", "arXiv api core")
+	w.WriteString("    </author>\n")
+	w.WriteString("  </entry>\n")
+	w.WriteString("</feed>\n")
+	return w.Bytes()
+}
+
+func (w *feedWriter) entry(p Paper) {
+	id := p.VersionedID()
+	w.WriteString("  <entry>\n")
+	w.element("    ", "id", "http://arxiv.org/abs/"+id)
+	w.element("    ", "updated", p.updated().UTC().Format(timeLayout))
+	w.element("    ", "published", p.Published.UTC().Format(timeLayout))
+	w.element("    ", "title", p.Title)
+	w.element("    ", "summary", p.Summary)
+	for _, a := range p.Authors {
+		w.WriteString("    <author>\n")
+		w.element("      ", "name", a)
+		w.WriteString("    </author>\n")
+	}
+	if p.DOI != "" {
+		w.element("    ", "arxiv:doi", p.DOI)
+		fmt.Fprintf(w, "    <link title=\"doi\" href=\"%s\" rel=\"related\"/>\n", w.attr("http://dx.doi.org/"+p.DOI))
+	}
+	if p.Comment != "" {
+		w.element("    ", "arxiv:comment", p.Comment)
+	}
+	if p.JournalRef != "" {
+		w.element("    ", "arxiv:journal_ref", p.JournalRef)
+	}
+	fmt.Fprintf(w, "    <link href=\"%s\" rel=\"alternate\" type=\"text/html\"/>\n", w.attr("http://arxiv.org/abs/"+id))
+	fmt.Fprintf(w, "    <link title=\"pdf\" href=\"%s\" rel=\"related\" type=\"application/pdf\"/>\n", w.attr("http://arxiv.org/pdf/"+id))
+	if len(p.Categories) > 0 {
+		fmt.Fprintf(w, "    <arxiv:primary_category term=\"%s\" scheme=%q/>\n", w.attr(p.Categories[0]), arxivNS)
+	}
+	for _, c := range p.Categories {
+		fmt.Fprintf(w, "    <category term=\"%s\" scheme=%q/>\n", w.attr(c), arxivNS)
+	}
+	w.WriteString("  </entry>\n")
+}
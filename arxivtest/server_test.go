@@ -0,0 +1,350 @@
+package arxivtest_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+var corpus = []arxivtest.Paper{
+	{
+		ID:         "2401.00001",
+		Title:      "Attention Is Still All You Need",
+		Summary:    "We revisit transformers for sequence modelling.",
+		Authors:    []string{"Ada Lovelace", "Alan Turing"},
+		Published:  time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		Updated:    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Version:    3,
+		Categories: []string{"cs.LG", "cs.CL"},
+		Comment:    "12 pages",
+		DOI:        "10.1000/xyz123",
+	},
+	{
+		ID:         "2401.00002",
+		Title:      "Graph Neural Networks for Chemistry",
+		Summary:    "Message passing on molecular graphs.",
+		Authors:    []string{"Marie Curie"},
+		Published:  time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		Categories: []string{"physics.chem-ph", "cs.LG"},
+	},
+	{
+		ID:         "2401.00003",
+		Title:      "Black Holes and Information",
+		Summary:    "A review of the information paradox.",
+		Authors:    []string{"Stephen Hawking"},
+		Published:  time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC),
+		Updated:    time.Date(2024, 1, 4, 10, 0, 0, 0, time.UTC),
+		Categories: []string{"hep-th", "gr-qc"},
+		JournalRef: "Phys. Rev. D 100, 1 (2024)",
+	},
+	{
+		ID:         "hep-th/9901001",
+		Title:      "Strings in Old Style",
+		Summary:    "An old-style identifier.",
+		Authors:    []string{"Edward Witten"},
+		Published:  time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"hep-th"},
+	},
+}
+
+type response struct {
+	Status       int
+	TotalResults int      `xml:"totalResults"`
+	StartIndex   int      `xml:"startIndex"`
+	ItemsPerPage int      `xml:"itemsPerPage"`
+	IDs          []string `xml:"entry>id"`
+	Titles       []string `xml:"entry>title"`
+	Summaries    []string `xml:"entry>summary"`
+}
+
+func query(t *testing.T, srv *arxivtest.Server, params url.Values) response {
+	t.Helper()
+	is := is.New(t)
+
+	resp, err := http.Get(srv.URL() + "?" + params.Encode())
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	var r response
+	is.NoErr(xml.NewDecoder(resp.Body).Decode(&r))
+	r.Status = resp.StatusCode
+	return r
+}
+
+func newServer(t *testing.T, opts ...arxivtest.Option) *arxivtest.Server {
+	srv := arxivtest.NewServer(corpus, opts...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestServer_Paging(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	params := url.Values{"search_query": {"cat:cs.LG"}, "max_results": {"1"}, "sortBy": {"submittedDate"}, "sortOrder": {"ascending"}}
+	r := query(t, srv, params)
+	is.Equal(r.Status, http.StatusOK)
+	is.Equal(r.TotalResults, 2)
+	is.Equal(r.ItemsPerPage, 1)
+	is.Equal(r.IDs, []string{"http://arxiv.org/abs/2401.00001v3"})
+
+	params.Set("start", "1")
+	r = query(t, srv, params)
+	is.Equal(r.StartIndex, 1)
+	is.Equal(r.IDs, []string{"http://arxiv.org/abs/2401.00002v1"})
+
+	params.Set("start", "2")
+	r = query(t, srv, params)
+	is.Equal(r.TotalResults, 2)
+	is.Equal(len(r.IDs), 0)
+}
+
+func TestServer_Sorting(t *testing.T) {
+	tests := []struct {
+		sortBy, sortOrder string
+		want              []string
+	}{
+		{"relevance", "descending", []string{"2401.00001v3", "2401.00002v1", "2401.00003v1", "hep-th/9901001v1"}},
+		{"relevance", "ascending", []string{"hep-th/9901001v1", "2401.00003v1", "2401.00002v1", "2401.00001v3"}},
+		{"submittedDate", "descending", []string{"2401.00003v1", "2401.00002v1", "2401.00001v3", "hep-th/9901001v1"}},
+		{"lastUpdatedDate", "descending", []string{"2401.00001v3", "2401.00003v1", "2401.00002v1", "hep-th/9901001v1"}},
+		{"lastUpdatedDate", "ascending", []string{"hep-th/9901001v1", "2401.00002v1", "2401.00003v1", "2401.00001v3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy+"_"+tt.sortOrder, func(t *testing.T) {
+			is := is.New(t)
+			srv := newServer(t)
+
+			r := query(t, srv, url.Values{"sortBy": {tt.sortBy}, "sortOrder": {tt.sortOrder}})
+			is.Equal(trimIDs(r.IDs), tt.want)
+		})
+	}
+}
+
+func TestServer_Query(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"ti:transformers", nil},
+		{"abs:transformers", []string{"2401.00001v3"}},
+		{`ti:"neural networks"`, []string{"2401.00002v1"}},
+		{"au:curie", []string{"2401.00002v1"}},
+		{"au:stephen_hawking", []string{"2401.00003v1"}},
+		{"cat:hep-th", []string{"2401.00003v1", "hep-th/9901001v1"}},
+		{"cat:physics.*", []string{"2401.00002v1"}},
+		{"cat:cs.LG AND au:turing", []string{"2401.00001v3"}},
+		{"cat:cs.LG ANDNOT au:turing", []string{"2401.00002v1"}},
+		{"(au:curie OR au:witten) AND all:strings", []string{"hep-th/9901001v1"}},
+		{"jr:phys", []string{"2401.00003v1"}},
+		{"co:pages", []string{"2401.00001v3"}},
+		{"information", []string{"2401.00003v1"}},
+		{"submittedDate:[20240102 TO 202401031000]", []string{"2401.00002v1", "2401.00003v1"}},
+		{"lastUpdatedDate:[202402010000 TO 202412312359]", []string{"2401.00001v3"}},
+		// papers without an update were last updated when they were published
+		{"lastUpdatedDate:[20240102 TO 20240102]", []string{"2401.00002v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			is := is.New(t)
+			srv := newServer(t)
+
+			r := query(t, srv, url.Values{"search_query": {tt.query}})
+			is.Equal(r.Status, http.StatusOK)
+			is.Equal(trimIDs(r.IDs), tt.want)
+			is.Equal(r.TotalResults, len(tt.want))
+		})
+	}
+}
+
+func TestServer_IDList(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	r := query(t, srv, url.Values{"id_list": {"hep-th/9901001,2401.00001v2,2401.09999"}})
+	is.Equal(trimIDs(r.IDs), []string{"hep-th/9901001v1", "2401.00001v2"})
+
+	// a search query narrows down the id_list
+	r = query(t, srv, url.Values{"id_list": {"hep-th/9901001,2401.00001"}, "search_query": {"cat:cs.LG"}})
+	is.Equal(trimIDs(r.IDs), []string{"2401.00001v3"})
+}
+
+func TestServer_ErrorEntries(t *testing.T) {
+	tests := []struct {
+		params  url.Values
+		summary string
+	}{
+		{url.Values{"id_list": {"not-an-id"}}, "incorrect id format for not-an-id"},
+		{url.Values{"start": {"-1"}}, "start must be an integer"},
+		{url.Values{"max_results": {"30001"}}, "max_results must be less than or equal to 30000"},
+		{url.Values{"search_query": {"(ti:open"}}, "invalid search_query: missing closing parenthesis"},
+		{url.Values{"search_query": {"foo:bar"}}, `invalid search_query: unknown field "foo"`},
+		{url.Values{"sortBy": {"title"}}, "sortBy must be in: relevance, lastUpdatedDate, submittedDate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.summary, func(t *testing.T) {
+			is := is.New(t)
+			srv := newServer(t)
+
+			r := query(t, srv, tt.params)
+			is.Equal(r.Status, http.StatusBadRequest)
+			is.Equal(r.TotalResults, 1)
+			is.Equal(r.Titles, []string{"Error"})
+			is.Equal(r.Summaries, []string{tt.summary})
+		})
+	}
+}
+
+func TestServer_ResultCap(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t, arxivtest.WithResultCap(3))
+
+	r := query(t, srv, url.Values{"start": {"2"}, "max_results": {"3"}})
+	is.Equal(r.TotalResults, 3)
+	is.Equal(trimIDs(r.IDs), []string{"2401.00003v1"})
+}
+
+func TestServer_Faults(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	srv.InjectFaults(
+		arxivtest.Fault{Status: http.StatusServiceUnavailable, Body: "Rate exceeded."},
+		arxivtest.Fault{Empty: true},
+		arxivtest.Fault{Delay: 50 * time.Millisecond},
+	)
+
+	resp, err := http.Get(srv.URL())
+	is.NoErr(err)
+	body, err := io.ReadAll(resp.Body)
+	is.NoErr(err)
+	_ = resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusServiceUnavailable)
+	is.Equal(string(body), "Rate exceeded.")
+
+	r := query(t, srv, nil)
+	is.Equal(r.TotalResults, 4)
+	is.Equal(len(r.IDs), 0)
+
+	start := time.Now()
+	r = query(t, srv, nil)
+	is.True(time.Since(start) >= 50*time.Millisecond)
+	is.Equal(len(r.IDs), 4)
+
+	is.Equal(len(srv.Requests()), 3)
+}
+
+func TestServer_SlowResponseCancelled(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+	srv.InjectFaults(arxivtest.Fault{Delay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL(), nil)
+	is.NoErr(err)
+	_, err = http.DefaultClient.Do(req)
+	is.True(err != nil)
+}
+
+func TestServer_AddRemove(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	updated := corpus[1]
+	updated.Version = 2
+	srv.Add(updated, arxivtest.Paper{ID: "2402.00001", Title: "New", Published: time.Now()})
+	srv.Remove("hep-th/9901001")
+
+	r := query(t, srv, nil)
+	is.Equal(trimIDs(r.IDs), []string{"2401.00001v3", "2401.00002v2", "2401.00003v1", "2402.00001v1"})
+}
+
+func TestParseFeed_RoundTrip(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	resp, err := http.Get(srv.URL())
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	papers, err := arxivtest.ParseFeed(resp.Body)
+	is.NoErr(err)
+	is.Equal(papers, corpusWithDefaults())
+}
+
+func TestLoadFeed(t *testing.T) {
+	is := is.New(t)
+
+	papers, err := arxivtest.LoadFeed("testdata/feed.xml")
+	is.NoErr(err)
+	is.Equal(len(papers), 2)
+	is.Equal(papers[0].ID, "2406.01234")
+	is.Equal(papers[0].Version, 1)
+	is.Equal(papers[0].Categories, []string{"cs.LG", "cs.CL"})
+	is.Equal(papers[1].VersionedID(), "2406.01200v2")
+}
+
+// corpusWithDefaults returns the corpus as it is returned by the API.
+func corpusWithDefaults() []arxivtest.Paper {
+	out := make([]arxivtest.Paper, len(corpus))
+	for i, p := range corpus {
+		if p.Version == 0 {
+			p.Version = 1
+		}
+		if p.Updated.IsZero() {
+			p.Updated = p.Published
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func trimIDs(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		out = append(out, strings.TrimPrefix(id, "http://arxiv.org/abs/"))
+	}
+	return out
+}
+
+func TestServer_OAIGetRecord(t *testing.T) {
+	is := is.New(t)
+	srv := newServer(t)
+
+	get := func(params url.Values) string {
+		resp, err := http.Get(srv.OAIURL() + "?" + params.Encode())
+		is.NoErr(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		is.NoErr(err)
+		return string(b)
+	}
+
+	var record struct {
+		Versions []struct {
+			Version string `xml:"version,attr"`
+			Date    string `xml:"date"`
+		} `xml:"GetRecord>record>metadata>arXivRaw>version"`
+	}
+	body := get(url.Values{"verb": {"GetRecord"}, "metadataPrefix": {"arXivRaw"}, "identifier": {"oai:arXiv.org:2401.00001"}})
+	is.NoErr(xml.Unmarshal([]byte(body), &record))
+	is.Equal(len(record.Versions), 3)
+	is.Equal(record.Versions[0].Version, "v1")
+	is.Equal(record.Versions[0].Date, "Mon, 1 Jan 2024 10:00:00 GMT")
+	is.Equal(record.Versions[2].Date, "Fri, 1 Mar 2024 10:00:00 GMT")
+
+	body = get(url.Values{"verb": {"GetRecord"}, "metadataPrefix": {"arXivRaw"}, "identifier": {"oai:arXiv.org:2401.99999"}})
+	is.True(strings.Contains(body, `<error code="idDoesNotExist">`))
+
+	body = get(url.Values{"verb": {"ListRecords"}})
+	is.True(strings.Contains(body, `<error code="badVerb">`))
+}
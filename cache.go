@@ -0,0 +1,363 @@
+package arxiv
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+const (
+	CacheModeReadWrite = "read_write"
+	CacheModeReplay    = "replay"
+)
+
+var errCacheMiss = errors.New("response not found in cache")
+
+type revalidateKey struct{}
+
+// withRevalidation marks requests made with the returned context to be
+// revalidated with arXiv even if their cached response is within the TTL.
+// It is used for lookups that must see the latest state of a paper.
+func withRevalidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func mustRevalidate(ctx context.Context) bool {
+	v, _ := ctx.Value(revalidateKey{}).(bool)
+	return v
+}
+
+// CacheConfig configures the on-disk response cache.
+type CacheConfig struct {
+	// Dir is the directory where responses are cached. The cache is disabled
+	// if empty.
+	Dir string `json:"dir"`
+	// TTL is how long a cached response is served without contacting arXiv.
+	// Once expired, the response is revalidated with a conditional request.
+	// Reconciliation and version history lookups are always revalidated.
+	TTL time.Duration `json:"ttl" default:"0s"`
+	// MaxSize is the maximum size in bytes of the cached responses. The
+	// least recently used responses are evicted once it is exceeded. 0
+	// disables the limit.
+	MaxSize int64 `json:"max_size" default:"1073741824"`
+	// Mode is either read_write (fetch and store responses) or replay (serve
+	// responses only from the cache and fail on a cache miss).
+	Mode string `json:"mode" default:"read_write"`
+}
+
+func (c CacheConfig) Validate() error {
+	if c.TTL < 0 {
+		return fmt.Errorf("cache.ttl must not be negative")
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("cache.max_size must not be negative")
+	}
+	if c.Mode != CacheModeReadWrite && c.Mode != CacheModeReplay {
+		return fmt.Errorf("cache.mode must be either read_write or replay")
+	}
+	if c.Mode == CacheModeReplay && c.Dir == "" {
+		return fmt.Errorf("cache.dir is required in replay mode")
+	}
+	return nil
+}
+
+// cacheEntry is the metadata stored next to a cached response body.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// cacheTransport serves responses from an on-disk cache keyed by the request
+// URL, and revalidates stale entries using ETag/Last-Modified.
+type cacheTransport struct {
+	base    http.RoundTripper
+	config  CacheConfig
+	maxSize int64
+	now     func() time.Time
+
+	// evictMu serializes evictions of concurrent requests.
+	evictMu sync.Mutex
+}
+
+func newCacheTransport(base http.RoundTripper, config CacheConfig, maxSize int64) (*cacheTransport, error) {
+	if err := os.MkdirAll(config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &cacheTransport{
+		base:    base,
+		config:  config,
+		maxSize: maxSize,
+		now:     time.Now,
+	}, nil
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req) //nolint:wrapcheck // errors are wrapped by the http.Client
+	}
+
+	key := t.key(req.URL.String())
+	entry, body, err := t.load(key)
+	if err != nil && !errors.Is(err, errCacheMiss) {
+		sdk.Logger(req.Context()).Warn().Err(err).Str("url", req.URL.String()).Msg("ignoring unreadable cache entry")
+	}
+	found := err == nil
+
+	if t.config.Mode == CacheModeReplay {
+		if !found {
+			return nil, fmt.Errorf("%w: %s", errCacheMiss, req.URL)
+		}
+		return cachedResponse(req, entry, body), nil
+	}
+
+	if found && t.now().Sub(entry.StoredAt) < t.config.TTL && !mustRevalidate(req.Context()) {
+		sdk.Logger(req.Context()).Debug().Str("url", req.URL.String()).Msg("serving response from cache")
+		t.touch(key)
+		return cachedResponse(req, entry, body), nil
+	}
+
+	if found {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors are wrapped by the http.Client
+	}
+
+	switch {
+	case found && resp.StatusCode == http.StatusNotModified:
+		_ = resp.Body.Close()
+		sdk.Logger(req.Context()).Debug().Str("url", req.URL.String()).Msg("cached response not modified")
+		entry.StoredAt = t.now()
+		if err := t.storeEntry(key, entry); err != nil {
+			return nil, err
+		}
+		return cachedResponse(req, entry, body), nil
+	case resp.StatusCode == http.StatusOK:
+		defer resp.Body.Close()
+		b, err := readAllLimited(resp.Body, t.maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		entry = cacheEntry{
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			StoredAt:     t.now(),
+		}
+		if err := t.store(key, entry, b); err != nil {
+			return nil, err
+		}
+		if err := t.evict(); err != nil {
+			sdk.Logger(req.Context()).Warn().Err(err).Msg("failed to evict cached responses")
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		resp.ContentLength = int64(len(b))
+		return resp, nil
+	default:
+		return resp, nil
+	}
+}
+
+func (t *cacheTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if c, ok := t.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func (t *cacheTransport) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *cacheTransport) paths(key string) (meta, body string) {
+	return filepath.Join(t.config.Dir, key+".json"), filepath.Join(t.config.Dir, key+".body")
+}
+
+func (t *cacheTransport) load(key string) (cacheEntry, []byte, error) {
+	metaPath, bodyPath := t.paths(key)
+
+	metaBytes, err := os.ReadFile(metaPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cacheEntry{}, nil, errCacheMiss
+	}
+	if err != nil {
+		return cacheEntry{}, nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(metaBytes, &entry); err != nil {
+		return cacheEntry{}, nil, fmt.Errorf("failed to parse cache entry: %w", err)
+	}
+
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return cacheEntry{}, nil, fmt.Errorf("failed to read cached body: %w", err)
+	}
+	return entry, body, nil
+}
+
+func (t *cacheTransport) store(key string, entry cacheEntry, body []byte) error {
+	_, bodyPath := t.paths(key)
+	if err := writeFileAtomic(bodyPath, body); err != nil {
+		return fmt.Errorf("failed to write cached body: %w", err)
+	}
+	return t.storeEntry(key, entry)
+}
+
+func (t *cacheTransport) storeEntry(key string, entry cacheEntry) error {
+	metaPath, _ := t.paths(key)
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := writeFileAtomic(metaPath, b); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// touch marks a cache entry as used, so it is evicted last.
+func (t *cacheTransport) touch(key string) {
+	metaPath, _ := t.paths(key)
+	now := t.now()
+	_ = os.Chtimes(metaPath, now, now)
+}
+
+// evict removes the least recently used cache entries until the cached
+// responses fit into the configured maximum size.
+func (t *cacheTransport) evict() error {
+	if t.config.MaxSize == 0 {
+		return nil
+	}
+	t.evictMu.Lock()
+	defer t.evictMu.Unlock()
+
+	files, err := os.ReadDir(t.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to list cache directory: %w", err)
+	}
+	type cached struct {
+		key  string
+		used time.Time
+		size int64
+	}
+	entries := make(map[string]*cached)
+	var total int64
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".json" && ext != ".body") {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue // removed in the meantime
+		}
+		key := strings.TrimSuffix(f.Name(), ext)
+		e, ok := entries[key]
+		if !ok {
+			e = &cached{key: key}
+			entries[key] = e
+		}
+		e.size += info.Size()
+		if ext == ".json" {
+			e.used = info.ModTime()
+		}
+		total += info.Size()
+	}
+	if total <= t.config.MaxSize {
+		return nil
+	}
+
+	lru := make([]*cached, 0, len(entries))
+	for _, e := range entries {
+		lru = append(lru, e)
+	}
+	sort.Slice(lru, func(i, j int) bool { return lru[i].used.Before(lru[j].used) })
+	for _, e := range lru {
+		if total <= t.config.MaxSize {
+			break
+		}
+		// the entry is removed first, so the body is never read without it
+		metaPath, bodyPath := t.paths(e.key)
+		if err := os.Remove(metaPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		if err := os.Remove(bodyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cached body: %w", err)
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+// cachedResponse builds a response for the request from a cache entry.
+func cachedResponse(req *http.Request, entry cacheEntry, body []byte) *http.Response {
+	header := make(http.Header)
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		header.Set("Last-Modified", entry.LastModified)
+	}
+	header.Set("X-Cache", "HIT")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// writeFileAtomic writes data to a temporary file and renames it, so readers
+// never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	if err := tmp.Close(); err != nil {
+		return err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	return os.Rename(tmp.Name(), path) //nolint:wrapcheck // errors are wrapped by the caller
+}
@@ -0,0 +1,169 @@
+package arxiv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+// createETagServer returns a server that serves mockArxivResponse with an
+// ETag and answers conditional requests with 304 Not Modified.
+func createETagServer(t *testing.T, full, notModified *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/atom+xml")
+		fmt.Fprintln(w, mockArxivResponse)
+	}))
+}
+
+func readFirstKey(ctx context.Context, t *testing.T, serverURL string, cfg map[string]string) string {
+	t.Helper()
+	is := is.New(t)
+
+	src := openTestSource(ctx, t, serverURL, cfg)
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.NoErr(src.Teardown(ctx))
+	return string(rec.Key.Bytes())
+}
+
+func TestCache_ConditionalRequest(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var full, notModified atomic.Int32
+	server := createETagServer(t, &full, &notModified)
+	defer server.Close()
+
+	cfg := map[string]string{"cache.dir": t.TempDir()}
+
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+
+	is.Equal(full.Load(), int32(1))
+	is.Equal(notModified.Load(), int32(1))
+}
+
+func TestCache_TTL(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var full, notModified atomic.Int32
+	server := createETagServer(t, &full, &notModified)
+	defer server.Close()
+
+	cfg := map[string]string{"cache.dir": t.TempDir(), "cache.ttl": "1h"}
+
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+
+	is.Equal(full.Load(), int32(1))
+	is.Equal(notModified.Load(), int32(0))
+}
+
+func TestCache_Replay(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var full, notModified atomic.Int32
+	server := createETagServer(t, &full, &notModified)
+	dir := t.TempDir()
+
+	is.Equal(readFirstKey(ctx, t, server.URL, map[string]string{"cache.dir": dir}), "2401.12345")
+	server.Close()
+
+	// replaying works without the server
+	replay := map[string]string{"cache.dir": dir, "cache.mode": "replay"}
+	is.Equal(readFirstKey(ctx, t, server.URL, replay), "2401.12345")
+
+	// a query that was never recorded is a cache miss
+	replay["search_query"] = "quantum"
+	src := openTestSource(ctx, t, server.URL, replay)
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "response not found in cache"))
+}
+
+func TestCache_MaxSize(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var full, notModified atomic.Int32
+	server := createETagServer(t, &full, &notModified)
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := map[string]string{"cache.dir": dir, "cache.max_size": "1"}
+
+	// the response is larger than the cache, so it is evicted right away
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+	is.Equal(readFirstKey(ctx, t, server.URL, cfg), "2401.12345")
+
+	is.Equal(full.Load(), int32(2))
+	is.Equal(notModified.Load(), int32(0))
+	files, err := os.ReadDir(dir)
+	is.NoErr(err)
+	is.Equal(len(files), 0)
+}
+
+func TestCache_TTLRevalidatesVersionHistory(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer([]arxivtest.Paper{{
+		ID:         "2401.00001",
+		Summary:    "A regular abstract.",
+		Published:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"cs.LG"},
+	}})
+	t.Cleanup(server.Close)
+
+	cfg := map[string]string{
+		"search_query":            "cat:cs.LG",
+		"cache.dir":               t.TempDir(),
+		"cache.ttl":               "1h",
+		"version_history.enabled": "true",
+		"version_history.url":     server.OAIURL(),
+	}
+	countRequests := func() (queries, histories int) {
+		for _, r := range server.Requests() {
+			if r.Get("verb") == "GetRecord" {
+				histories++
+			} else {
+				queries++
+			}
+		}
+		return queries, histories
+	}
+	read := func() {
+		src := openTestSource(ctx, t, server.URL(), cfg)
+		is.Equal(len(readAll(ctx, t, src)), 1)
+		is.NoErr(src.Teardown(ctx))
+	}
+
+	read()
+	queries, histories := countRequests()
+	is.Equal(histories, 1)
+
+	// the queries are served from the cache, the version history isn't
+	read()
+	q, h := countRequests()
+	is.Equal(q, queries)
+	is.Equal(h, histories+1)
+}
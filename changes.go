@@ -0,0 +1,132 @@
+package arxiv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// fieldHash returns the hash of a payload value. Whitespace in strings is
+// collapsed, so reflowed titles and abstracts don't count as changes.
+func fieldHash(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		v = collapseSpace(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// changedFields returns the sorted original names of the payload fields that
+// differ between two states of a paper, leaving out dropped fields.
+func (m *fieldMapper) changedFields(prev, cur paperState) []string {
+	var changed []string
+	for name, h := range cur.Fields {
+		if prev.Fields[name] != h && !m.drop[name] {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev.Fields {
+		if _, ok := cur.Fields[name]; !ok && !m.drop[name] {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// detectChange compares a record with the state in which its paper was last
+// emitted and reports whether it should be emitted. Records of papers that
+// were emitted before are turned into updates, with the previous payload as
+// Before and the changed fields in the arxiv.changed_fields metadata field.
+// Deletes of papers that were already deleted are skipped, papers emitted
+// again after a delete are created anew.
+func (s *Source) detectChange(rec *opencdc.Record, st paperState) (bool, error) {
+	stored, ok := s.state.lookup(st.id)
+	switch {
+	case !ok:
+		return true, nil
+	case st.deleted:
+		return !stored.deleted, nil
+	case stored.deleted:
+		return true, nil
+	case stored.hash == st.Hash:
+		return false, nil
+	}
+	prev, err := s.state.load(st.id)
+	if err != nil {
+		return false, err
+	}
+
+	rec.Operation = opencdc.OperationUpdate
+	rec.Metadata["arxiv.changed_fields"] = strings.Join(s.mapper.changedFields(prev, st), ",")
+
+	before, err := s.previousPayload(prev)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode previous payload of %s: %w", st.id, err)
+	}
+	rec.Payload.Before = before
+	return true, nil
+}
+
+// previousPayload returns the payload a paper was last emitted with, nil if
+// it wasn't stored in the output format of the source.
+func (s *Source) previousPayload(prev paperState) (opencdc.Data, error) {
+	switch s.config.OutputFormat {
+	case OutputFormatRawXML, OutputFormatJSON:
+		if prev.RawPayload == nil {
+			return nil, nil
+		}
+		return opencdc.RawData(prev.RawPayload), nil
+	}
+	if len(prev.Payload) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(prev.Payload))
+	dec.UseNumber()
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	for k, v := range data {
+		data[k] = restoreNumbers(v)
+	}
+	if s.payloadSchema != nil {
+		if err := s.payloadSchema.typed(data); err != nil {
+			return nil, err
+		}
+	}
+	return opencdc.StructuredData(data), nil
+}
+
+// restoreNumbers turns the JSON numbers in a decoded payload value back into
+// integers, the payload doesn't contain any other numbers.
+func restoreNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = restoreNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = restoreNumbers(v[k])
+		}
+	}
+	return v
+}
@@ -0,0 +1,180 @@
+package arxiv_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func TestSource_ChangeDetection(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	corpus := []arxivtest.Paper{
+		{
+			ID:         "2401.00001",
+			Title:      "Scaling laws",
+			Summary:    "We study scaling laws.",
+			Authors:    []string{"Jane Doe"},
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+		{
+			ID:         "2401.00002",
+			Title:      "Sparse models",
+			Summary:    "We study sparse models.",
+			Authors:    []string{"John Roe"},
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+	}
+	server := arxivtest.NewServer(corpus)
+	t.Cleanup(server.Close)
+
+	cfg := map[string]string{
+		"search_query": "cat:cs.LG",
+		"sort_by":      "relevance",
+		"state_file":   filepath.Join(t.TempDir(), "state.json"),
+	}
+
+	src := openTestSource(ctx, t, server.URL(), cfg)
+	recs := readAll(ctx, t, src)
+	is.Equal(len(recs), 2)
+	for _, rec := range recs {
+		is.Equal(rec.Operation, opencdc.OperationCreate)
+		is.NoErr(src.Ack(ctx, rec.Position))
+	}
+	is.NoErr(src.Teardown(ctx))
+
+	// the abstract of the first paper changed, the second one was only
+	// reflowed
+	changed := corpus[0]
+	changed.Summary = "We study scaling laws of language models."
+	reflowed := corpus[1]
+	reflowed.Summary = "We study\n  sparse models."
+	server.Add(changed, reflowed, arxivtest.Paper{
+		ID:         "2401.00003",
+		Title:      "Mixture of experts",
+		Summary:    "We study mixtures of experts.",
+		Authors:    []string{"Jane Doe"},
+		Published:  published,
+		Categories: []string{"cs.LG"},
+	})
+
+	src = openTestSource(ctx, t, server.URL(), cfg)
+	recs = readAll(ctx, t, src)
+	is.Equal(len(recs), 2)
+
+	is.Equal(recs[0].Operation, opencdc.OperationUpdate)
+	is.Equal(recs[0].Metadata["arxiv.changed_fields"], "abstract")
+	before := recs[0].Payload.Before.(opencdc.StructuredData)
+	after := recs[0].Payload.After.(opencdc.StructuredData)
+	is.Equal(before["abstract"], "We study scaling laws.")
+	is.Equal(after["abstract"], "We study scaling laws of language models.")
+	is.Equal(before["title"], after["title"])
+
+	is.Equal(recs[1].Operation, opencdc.OperationCreate)
+	is.Equal(recs[1].Key, opencdc.RawData("2401.00003"))
+	_, ok := recs[1].Metadata["arxiv.changed_fields"]
+	is.True(!ok)
+}
+
+func TestSource_ChangeDetectionNewVersion(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	paper := arxivtest.Paper{
+		ID:         "2401.00001",
+		Title:      "Scaling laws",
+		Summary:    "We study scaling laws.",
+		Authors:    []string{"Jane Doe"},
+		Published:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"cs.LG"},
+	}
+	server := arxivtest.NewServer([]arxivtest.Paper{paper})
+	t.Cleanup(server.Close)
+
+	cfg := map[string]string{
+		"search_query": "cat:cs.LG",
+		"state_file":   filepath.Join(t.TempDir(), "state.json"),
+	}
+
+	src := openTestSource(ctx, t, server.URL(), cfg)
+	created := readAll(ctx, t, src)
+	is.Equal(len(created), 1)
+	is.NoErr(src.Ack(ctx, created[0].Position))
+	is.NoErr(src.Teardown(ctx))
+
+	paper.Version = 2
+	paper.Updated = paper.Published.Add(24 * time.Hour)
+	paper.Summary = "We study scaling laws of language models."
+	server.Add(paper)
+
+	// the update of a new version is keyed like the create of the first one
+	src = openTestSource(ctx, t, server.URL(), cfg)
+	updated := readAll(ctx, t, src)
+	is.Equal(len(updated), 1)
+	is.Equal(updated[0].Operation, opencdc.OperationUpdate)
+	is.Equal(updated[0].Metadata["arxiv.version"], "2")
+	is.Equal(updated[0].Key, created[0].Key)
+}
+
+func TestSource_ChangeDetectionBefore(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	paper := arxivtest.Paper{
+		ID:         "2401.00001",
+		Version:    2,
+		Title:      "Scaling laws",
+		Summary:    "We study scaling laws.",
+		Comment:    "12 pages, 3 figures",
+		Authors:    []string{"Jane Doe"},
+		Published:  published,
+		Categories: []string{"cs.LG"},
+		Versions: []arxivtest.Version{
+			{Submitted: published, Size: "120kb"},
+			{Submitted: published.Add(time.Hour), Size: "1kb", Withdrawn: true},
+		},
+	}
+	server := arxivtest.NewServer([]arxivtest.Paper{paper})
+	t.Cleanup(server.Close)
+
+	cfg := map[string]string{
+		"search_query":                       "cat:cs.LG",
+		"state_file":                         filepath.Join(t.TempDir(), "state.json"),
+		"version_history.enabled":            "true",
+		"version_history.url":                server.OAIURL(),
+		"withdrawn_mode":                     "flag",
+		"sdk.schema.extract.payload.enabled": "true",
+	}
+
+	src := openTestSource(ctx, t, server.URL(), cfg)
+	created := readAll(ctx, t, src)
+	is.Equal(len(created), 1)
+	is.NoErr(src.Ack(ctx, created[0].Position))
+	is.NoErr(src.Teardown(ctx))
+
+	paper.Summary = "We study scaling laws of language models."
+	server.Add(paper)
+
+	// Before is the payload the paper was emitted with, including the
+	// withdrawal that is only known from the version history
+	src = openTestSource(ctx, t, server.URL(), cfg)
+	updated := readAll(ctx, t, src)
+	is.Equal(len(updated), 1)
+	is.Equal(updated[0].Operation, opencdc.OperationUpdate)
+
+	before := updated[0]
+	before.Payload.After = before.Payload.Before
+	prev := decodeTypedPayload(ctx, t, before)
+	is.Equal(prev, decodeTypedPayload(ctx, t, created[0]))
+	is.Equal(prev["withdrawn"], true)
+}
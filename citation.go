@@ -0,0 +1,353 @@
+package arxiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	CitationFormatBibTeX  = "bibtex"
+	CitationFormatRIS     = "ris"
+	CitationFormatCSLJSON = "csl_json"
+)
+
+var citationFields = map[string]string{
+	CitationFormatBibTeX:  "citation_bibtex",
+	CitationFormatRIS:     "citation_ris",
+	CitationFormatCSLJSON: "citation_csl_json",
+}
+
+// validateCitationFormats checks the formats listed in the citations option.
+func validateCitationFormats(formats []string) error {
+	for _, f := range formats {
+		if _, ok := citationFields[f]; !ok {
+			return fmt.Errorf("unknown citation format %q, must be one of: bibtex, ris, csl_json", f)
+		}
+	}
+	return nil
+}
+
+// citation contains the parts of an entry needed to cite it.
+type citation struct {
+	key             string
+	id              Identifier
+	title           string
+	abstract        string
+	authors         []citationAuthor
+	published       time.Time
+	primaryCategory string
+	categories      []string
+}
+
+type citationAuthor struct {
+	family string
+	given  string
+	suffix string
+}
+
+func newCitation(entry ArxivEntry, id Identifier) citation {
+	c := citation{
+		id:              id.WithVersion(0),
+		title:           collapseSpace(entry.Title),
+		abstract:        collapseSpace(entry.Summary),
+		published:       entry.Published,
+		primaryCategory: entry.PrimaryCategoryTerm(),
+	}
+	for _, a := range entry.Authors {
+		c.authors = append(c.authors, splitAuthorName(a.Name))
+	}
+	for _, cat := range entry.Category {
+		c.categories = append(c.categories, cat.Term)
+	}
+	c.key = c.citationKey()
+	return c
+}
+
+// citationKey returns a key built from the family name of the first author,
+// the year of the first version and the first significant word of the title,
+// followed by the versionless arXiv identifier to keep it unique, e.g.
+// lovelace2024scaling_2401.00001.
+func (c citation) citationKey() string {
+	var b strings.Builder
+	if len(c.authors) > 0 {
+		b.WriteString(keyPart(c.authors[0].family))
+	}
+	if b.Len() == 0 {
+		b.WriteString("arxiv")
+	}
+	b.WriteString(strconv.Itoa(c.published.Year()))
+	for _, word := range strings.Fields(c.title) {
+		word = keyPart(word)
+		if len(word) > 3 && !titleStopWords[word] {
+			b.WriteString(word)
+			break
+		}
+	}
+	b.WriteString("_" + strings.ReplaceAll(c.id.Base(), "/", "_"))
+	return b.String()
+}
+
+var titleStopWords = map[string]bool{
+	"about": true, "from": true, "into": true, "over": true, "that": true,
+	"their": true, "there": true, "these": true, "this": true, "towards": true,
+	"when": true, "where": true, "which": true, "with": true, "without": true,
+}
+
+// keyPart folds s to lowercase ASCII letters and digits.
+func keyPart(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+var nameSuffixes = map[string]bool{"jr": true, "jr.": true, "sr": true, "sr.": true, "ii": true, "iii": true, "iv": true}
+
+// splitAuthorName splits a name as reported by arXiv ("First Middle Last
+// Jr.") into family name, given names and suffix.
+func splitAuthorName(name string) citationAuthor {
+	parts := strings.Fields(name)
+	var a citationAuthor
+	if n := len(parts); n > 1 && nameSuffixes[strings.ToLower(parts[n-1])] {
+		a.suffix = parts[n-1]
+		parts = parts[:n-1]
+	}
+	if len(parts) == 0 {
+		return a
+	}
+	a.family = parts[len(parts)-1]
+	a.given = strings.Join(parts[:len(parts)-1], " ")
+	return a
+}
+
+// bibTeXName returns the name in the "Family, Suffix, Given" form used by
+// BibTeX.
+func (a citationAuthor) bibTeXName() string {
+	name := a.family
+	if a.suffix != "" {
+		name += ", " + a.suffix
+	}
+	if a.given != "" {
+		name += ", " + a.given
+	}
+	return name
+}
+
+// risName returns the name in the "Family, Given, Suffix" form used by RIS.
+func (a citationAuthor) risName() string {
+	name := a.family
+	if a.given != "" || a.suffix != "" {
+		name += ", " + a.given
+	}
+	if a.suffix != "" {
+		name += ", " + a.suffix
+	}
+	return name
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// render returns the citation in the given format.
+func (c citation) render(format string) (string, error) {
+	switch format {
+	case CitationFormatBibTeX:
+		return c.bibTeX(), nil
+	case CitationFormatRIS:
+		return c.ris(), nil
+	case CitationFormatCSLJSON:
+		return c.cslJSON()
+	default:
+		return "", fmt.Errorf("unknown citation format %q", format)
+	}
+}
+
+// bibTeXEscape escapes the characters that are special in BibTeX values.
+// arXiv titles often contain LaTeX, so $...$ math spans, commands like
+// \emph and balanced braces are kept as they are.
+func bibTeXEscape(s string) string {
+	balanced := bracesBalanced(s)
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '$':
+			if end := strings.IndexByte(s[i+1:], '$'); end > 0 {
+				b.WriteString(s[i : i+end+2])
+				i += end + 1
+				continue
+			}
+			b.WriteString(`\$`)
+		case '\\':
+			n := latexCommandLength(s[i:])
+			if n == 0 {
+				b.WriteString(`\textbackslash{}`)
+				continue
+			}
+			b.WriteString(s[i : i+n])
+			i += n - 1
+		case '{', '}':
+			if !balanced {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		case '&', '%', '#', '_':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// latexCommandLength returns the length of the LaTeX command s starts with,
+// either a backslash followed by letters or by a single symbol, or 0 if s
+// doesn't start with a command.
+func latexCommandLength(s string) int {
+	n := 1
+	for n < len(s) && ('a' <= s[n] && s[n] <= 'z' || 'A' <= s[n] && s[n] <= 'Z') {
+		n++
+	}
+	if n > 1 {
+		return n
+	}
+	if n < len(s) && strings.IndexByte(`{}$&%#_'"^~.=`+"`", s[n]) >= 0 {
+		return 2
+	}
+	return 0
+}
+
+// bracesBalanced reports whether the unescaped braces in s are balanced.
+func bracesBalanced(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func (c citation) bibTeX() string {
+	authors := make([]string, len(c.authors))
+	for i, a := range c.authors {
+		authors[i] = a.bibTeXName()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@misc{%s,\n", c.key)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %-13s = {%s},\n", name, bibTeXEscape(value))
+		}
+	}
+	field("title", c.title)
+	field("author", strings.Join(authors, " and "))
+	field("year", strconv.Itoa(c.published.Year()))
+	// months are written as the predefined macros, which styles localize
+	fmt.Fprintf(&b, "  %-13s = %s,\n", "month", strings.ToLower(c.published.Format("Jan")))
+	field("eprint", c.id.String())
+	field("archivePrefix", "arXiv")
+	field("primaryClass", c.primaryCategory)
+	field("doi", c.id.DOI())
+	field("url", c.id.AbsURL())
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (c citation) ris() string {
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s  - %s\r\n", name, value)
+		}
+	}
+	tag("TY", "UNPB")
+	tag("ID", c.key)
+	tag("TI", c.title)
+	for _, a := range c.authors {
+		tag("AU", a.risName())
+	}
+	tag("PY", strconv.Itoa(c.published.Year()))
+	tag("DA", c.published.Format("2006/01/02"))
+	tag("AB", c.abstract)
+	for _, cat := range c.categories {
+		tag("KW", cat)
+	}
+	tag("DO", c.id.DOI())
+	tag("UR", c.id.AbsURL())
+	tag("PB", "arXiv")
+	tag("M1", "arXiv:"+c.id.String())
+	b.WriteString("ER  - \r\n")
+	return b.String()
+}
+
+type cslItem struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Author    []cslAuthor `json:"author,omitempty"`
+	Issued    cslDate     `json:"issued"`
+	Abstract  string      `json:"abstract,omitempty"`
+	DOI       string      `json:"DOI"`
+	URL       string      `json:"URL"`
+	Number    string      `json:"number"`
+	Publisher string      `json:"publisher"`
+	Keyword   string      `json:"keyword,omitempty"`
+}
+
+type cslAuthor struct {
+	Family string `json:"family"`
+	Given  string `json:"given,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+func (c citation) cslJSON() (string, error) {
+	item := cslItem{
+		ID:    c.key,
+		Type:  "article",
+		Title: c.title,
+		Issued: cslDate{DateParts: [][]int{{
+			c.published.Year(), int(c.published.Month()), c.published.Day(),
+		}}},
+		Abstract:  c.abstract,
+		DOI:       c.id.DOI(),
+		URL:       c.id.AbsURL(),
+		Number:    "arXiv:" + c.id.String(),
+		Publisher: "arXiv",
+		Keyword:   strings.Join(c.categories, ", "),
+	}
+	for _, a := range c.authors {
+		item.Author = append(item.Author, cslAuthor{Family: a.family, Given: a.given, Suffix: a.suffix})
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode CSL-JSON: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,174 @@
+package arxiv_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func readCitationRecord(t *testing.T, paper arxivtest.Paper, formats string) opencdc.StructuredData {
+	t.Helper()
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer([]arxivtest.Paper{paper})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query": "cat:" + paper.Categories[0],
+		"citations":    formats,
+	})
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	return data
+}
+
+var citedPaper = arxivtest.Paper{
+	ID:         "2406.01234",
+	Version:    2,
+	Title:      "The Scaling Laws of\n  Sparse Mixture-of-Experts & 100% Fun",
+	Summary:    "We study\n scaling.",
+	Authors:    []string{"Kurt Gödel", "Martin Luther King Jr.", "Plato"},
+	Published:  time.Date(2024, 6, 3, 17, 59, 58, 0, time.UTC),
+	Updated:    time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC),
+	Categories: []string{"cs.LG", "cs.CL"},
+}
+
+func TestCitations_BibTeX(t *testing.T) {
+	is := is.New(t)
+
+	data := readCitationRecord(t, citedPaper, "bibtex")
+	is.Equal(data["citation_bibtex"], `@misc{godel2024scaling_2406.01234,
+  title         = {The Scaling Laws of Sparse Mixture-of-Experts \& 100\% Fun},
+  author        = {Gödel, Kurt and King, Jr., Martin Luther and Plato},
+  year          = {2024},
+  month         = jun,
+  eprint        = {2406.01234},
+  archivePrefix = {arXiv},
+  primaryClass  = {cs.LG},
+  doi           = {10.48550/arXiv.2406.01234},
+  url           = {https://arxiv.org/abs/2406.01234},
+}
+`)
+	_, ok := data["citation_ris"]
+	is.True(!ok)
+}
+
+func TestCitations_BibTeXLaTeX(t *testing.T) {
+	testCases := []struct {
+		title string
+		want  string
+	}{
+		{
+			title: `Learning $\alpha$-Divergences with \emph{Sparse} Priors & $O(n_1)$ Memory`,
+			want:  `Learning $\alpha$-Divergences with \emph{Sparse} Priors \& $O(n_1)$ Memory`,
+		},
+		{
+			title: `Costs of $5 in {unbalanced braces`,
+			want:  `Costs of \$5 in \{unbalanced braces`,
+		},
+		{
+			title: `A path like C:\ and a \"{u}mlaut`,
+			want:  `A path like C:\textbackslash{} and a \"{u}mlaut`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			is := is.New(t)
+			paper := citedPaper
+			paper.Title = tc.title
+			data := readCitationRecord(t, paper, "bibtex")
+			is.True(strings.Contains(data["citation_bibtex"].(string), "title         = {"+tc.want+"},\n"))
+		})
+	}
+}
+
+func TestCitations_RIS(t *testing.T) {
+	is := is.New(t)
+
+	data := readCitationRecord(t, citedPaper, "ris")
+	is.Equal(data["citation_ris"], strings.Join([]string{
+		"TY  - UNPB",
+		"ID  - godel2024scaling_2406.01234",
+		"TI  - The Scaling Laws of Sparse Mixture-of-Experts & 100% Fun",
+		"AU  - Gödel, Kurt",
+		"AU  - King, Martin Luther, Jr.",
+		"AU  - Plato",
+		"PY  - 2024",
+		"DA  - 2024/06/03",
+		"AB  - We study scaling.",
+		"KW  - cs.LG",
+		"KW  - cs.CL",
+		"DO  - 10.48550/arXiv.2406.01234",
+		"UR  - https://arxiv.org/abs/2406.01234",
+		"PB  - arXiv",
+		"M1  - arXiv:2406.01234",
+		"ER  - ",
+		"",
+	}, "\r\n"))
+}
+
+func TestCitations_CSLJSON(t *testing.T) {
+	is := is.New(t)
+
+	data := readCitationRecord(t, citedPaper, "csl_json")
+
+	var item map[string]interface{}
+	is.NoErr(json.Unmarshal([]byte(data["citation_csl_json"].(string)), &item))
+	is.Equal(item["id"], "godel2024scaling_2406.01234")
+	is.Equal(item["type"], "article")
+	is.Equal(item["title"], "The Scaling Laws of Sparse Mixture-of-Experts & 100% Fun")
+	is.Equal(item["author"], []interface{}{
+		map[string]interface{}{"family": "Gödel", "given": "Kurt"},
+		map[string]interface{}{"family": "King", "given": "Martin Luther", "suffix": "Jr."},
+		map[string]interface{}{"family": "Plato"},
+	})
+	is.Equal(item["issued"], map[string]interface{}{"date-parts": []interface{}{[]interface{}{2024.0, 6.0, 3.0}}})
+	is.Equal(item["DOI"], "10.48550/arXiv.2406.01234")
+	is.Equal(item["number"], "arXiv:2406.01234")
+	is.Equal(item["publisher"], "arXiv")
+}
+
+func TestCitations_StableKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		paper arxivtest.Paper
+		key   string
+	}{
+		{"stop words are skipped", arxivtest.Paper{ID: "2401.00001", Title: "Towards a Theory of Everything", Authors: []string{"Ada Lovelace"}}, "lovelace2024theory_2401.00001"},
+		{"same author, year and title", arxivtest.Paper{ID: "2401.00002", Title: "Towards a Theory of Everything", Authors: []string{"Ada Lovelace"}}, "lovelace2024theory_2401.00002"},
+		{"no authors", arxivtest.Paper{ID: "2401.00001", Title: "Untitled Work"}, "arxiv2024untitled_2401.00001"},
+		{"legacy identifier", arxivtest.Paper{ID: "hep-th/9901001", Title: "On Strings", Authors: []string{"E. Witten"}}, "witten2024strings_hep-th_9901001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			tt.paper.Published = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			tt.paper.Categories = []string{"hep-th"}
+			data := readCitationRecord(t, tt.paper, "bibtex,ris")
+			is.True(strings.HasPrefix(data["citation_bibtex"].(string), "@misc{"+tt.key+",\n"))
+			is.True(strings.Contains(data["citation_ris"].(string), "ID  - "+tt.key+"\r\n"))
+		})
+	}
+}
+
+func TestCitations_InvalidFormat(t *testing.T) {
+	is := is.New(t)
+
+	err := configureTestSource(context.Background(), arxiv.NewSource(), "http://localhost", map[string]string{
+		"citations": "bibtex,endnote",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `unknown citation format "endnote"`))
+}
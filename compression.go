@@ -0,0 +1,119 @@
+package arxiv
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var errResponseTooLarge = errors.New("response exceeds maximum size")
+
+// compressionTransport negotiates a compressed response and transparently
+// decompresses it while it's being read.
+type compressionTransport struct {
+	base http.RoundTripper
+}
+
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors are wrapped by the http.Client
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "gzip", "x-gzip":
+		resp.Body = &decompressingBody{body: resp.Body, newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}}
+	case "deflate":
+		resp.Body = &decompressingBody{body: resp.Body, newReader: newDeflateReader}
+	default:
+		return resp, nil
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func (t *compressionTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if c, ok := t.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// newDeflateReader reads a "deflate" encoded body. The HTTP spec defines it
+// as zlib wrapped data, but some servers send raw deflate streams, so both
+// are accepted.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deflate header: %w", err)
+	}
+	// zlib header: compression method 8 and a valid header checksum
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br) //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressingBody lazily creates the decompressing reader on first read, so
+// that errors surface when the body is read.
+type decompressingBody struct {
+	body      io.ReadCloser
+	newReader func(io.Reader) (io.ReadCloser, error)
+	reader    io.ReadCloser
+	err       error
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = b.newReader(b.body)
+		if b.err != nil {
+			b.err = fmt.Errorf("failed to decompress response: %w", b.err)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p) //nolint:wrapcheck // errors are wrapped by the caller
+}
+
+func (b *decompressingBody) Close() error {
+	if b.reader != nil {
+		_ = b.reader.Close()
+	}
+	return b.body.Close() //nolint:wrapcheck // errors are wrapped by the caller
+}
+
+// readAllLimited reads r until EOF, returning an error if more than limit
+// bytes are read. A limit of 0 disables the check.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r) //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors are wrapped by the caller
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w of %d bytes", errResponseTooLarge, limit)
+	}
+	return b, nil
+}
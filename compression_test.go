@@ -0,0 +1,77 @@
+package arxiv_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func createCompressingServer(t *testing.T, encoding string, body []byte) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), encoding) {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		var buf bytes.Buffer
+		var zw io.WriteCloser
+		switch encoding {
+		case "gzip":
+			zw = gzip.NewWriter(&buf)
+		case "deflate":
+			zw = zlib.NewWriter(&buf)
+		}
+		_, _ = zw.Write(body)
+		_ = zw.Close()
+
+		w.Header().Set("Content-Encoding", encoding)
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write(buf.Bytes())
+	}))
+}
+
+func TestCompression_Encodings(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+
+			server := createCompressingServer(t, encoding, []byte(mockArxivResponse))
+			defer server.Close()
+
+			src := openTestSource(ctx, t, server.URL, nil)
+
+			rec, err := src.Read(ctx)
+			is.NoErr(err)
+			is.Equal(string(rec.Key.Bytes()), "2401.12345")
+		})
+	}
+}
+
+func TestCompression_MaxResponseSize(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	// highly compressible body that expands to well over the limit
+	body := []byte(strings.Replace(mockArxivResponse, "Sample Summary", strings.Repeat("a", 1<<20), 1))
+
+	server := createCompressingServer(t, "gzip", body)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"http.max_response_size": "65536",
+	})
+
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "response exceeds maximum size of 65536 bytes"))
+}
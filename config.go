@@ -3,6 +3,7 @@ package arxiv
 import (
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 // Config contains shared config parameters, common to the source and
@@ -30,3 +31,24 @@ func (c Config) BuildArxivURL(searchQuery, sortBy, sortOrder string, start, maxR
 	baseURL.RawQuery = params.Encode()
 	return baseURL.String(), nil
 }
+
+// BuildArxivIDListURL constructs the arXiv API URL fetching the papers with
+// the given IDs. If searchQuery is not empty, only the papers matching it are
+// returned.
+func (c Config) BuildArxivIDListURL(ids []string, searchQuery string) (string, error) {
+	baseURL, err := url.Parse(c.ArxivAPIURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid arXiv API URL: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("id_list", strings.Join(ids, ","))
+	if searchQuery != "" {
+		params.Set("search_query", searchQuery)
+	}
+	params.Set("start", "0")
+	params.Set("max_results", fmt.Sprintf("%d", len(ids)))
+
+	baseURL.RawQuery = params.Encode()
+	return baseURL.String(), nil
+}
@@ -0,0 +1,85 @@
+package arxiv
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// apiErrorID is contained in the ID of the entry arXiv returns instead of
+// results when a request is invalid.
+const apiErrorID = "arxiv.org/api/errors"
+
+// apiError returns the message of the error entry arXiv returns for invalid
+// requests, if the feed consists of one.
+func (f *ArxivFeed) apiError() (string, bool) {
+	for _, entry := range f.Entries {
+		if strings.Contains(entry.ID, apiErrorID) {
+			return strings.TrimSpace(entry.Summary), true
+		}
+	}
+	return "", false
+}
+
+// parseFeed parses an Atom feed returned by the arXiv API. Besides decoding
+// the entries it keeps the raw bytes of every <entry> element, so they can be
+// passed through unchanged.
+func parseFeed(body []byte) (*ArxivFeed, error) {
+	feed := &ArxivFeed{}
+	dec := xml.NewDecoder(bytes.NewReader(body))
+
+	depth := 0
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local != "feed" {
+					return nil, fmt.Errorf("unexpected root element %q", t.Name.Local)
+				}
+				feed.XMLName = t.Name
+				depth++
+				continue
+			}
+			switch t.Name.Local {
+			case "entry":
+				entry := &ArxivEntry{}
+				if err := dec.DecodeElement(entry, &t); err != nil {
+					return nil, err
+				}
+				entry.raw = body[offset:dec.InputOffset()]
+				feed.Entries = append(feed.Entries, entry)
+			case "title":
+				if err := dec.DecodeElement(&feed.Title, &t); err != nil {
+					return nil, err
+				}
+			case "totalResults":
+				if err := dec.DecodeElement(&feed.TotalResults, &t); err != nil {
+					return nil, err
+				}
+			default:
+				if err := dec.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	if depth != 0 || feed.XMLName.Local == "" {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return feed, nil
+}
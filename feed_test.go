@@ -0,0 +1,136 @@
+package arxiv_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+)
+
+const rawEntry = `<entry>
+    <id>http://arxiv.org/abs/2401.12345v1</id>
+    <title>Sample   Title</title>
+    <summary>Sample Summary</summary>
+    <author><name>Author One</name></author>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-02T00:00:00Z</updated>
+    <link href="http://arxiv.org/abs/2401.12345v1" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/2401.12345v1" rel="related" type="application/pdf"/>
+    <category term="cs.LG" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>`
+
+const rawResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>ArXiv Query</title>
+  ` + rawEntry + `
+</feed>`
+
+func TestOutputFormat_RawXML(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, rawResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"output_format": "raw_xml",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "2401.12345")
+	is.Equal(string(rec.Payload.After.Bytes()), rawEntry)
+}
+
+func TestOutputFormat_JSON(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, rawResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"output_format": "json",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	raw, ok := rec.Payload.After.(opencdc.RawData)
+	is.True(ok)
+
+	var got map[string]interface{}
+	is.NoErr(json.Unmarshal(raw, &got))
+	is.Equal(got["id"], "http://arxiv.org/abs/2401.12345v1")
+	is.Equal(got["title"], "Sample   Title")
+	is.Equal(got["published"], "2025-06-01T00:00:00Z")
+	is.Equal(len(got["links"].([]interface{})), 2)
+	is.Equal(got["links"].([]interface{})[1], map[string]interface{}{
+		"href":  "http://arxiv.org/pdf/2401.12345v1",
+		"rel":   "related",
+		"type":  "application/pdf",
+		"title": "pdf",
+	})
+	is.Equal(got["categories"], []interface{}{
+		map[string]interface{}{"term": "cs.LG", "scheme": "http://arxiv.org/schemas/atom"},
+	})
+}
+
+func TestOutputFormat_Invalid(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, rawResponse)
+	defer server.Close()
+
+	err := openTestSourceErr(ctx, t, server.URL, map[string]string{
+		"output_format": "yaml",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "output_format must be one of: structured, raw_xml, json"))
+}
+
+func TestSource_SkipsInvalidEntries(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/not-an-id</id>
+    <title>Broken</title>
+  </entry>
+  `+rawEntry+`
+</feed>`)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, nil)
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "2401.12345")
+}
+
+func TestSource_APIErrorEntry(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/api/errors#incorrect_id_format_for_1234</id>
+    <title>Error</title>
+    <summary>incorrect id format for 1234</summary>
+  </entry>
+</feed>`)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, nil)
+
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "incorrect id format for 1234"))
+}
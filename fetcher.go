@@ -0,0 +1,85 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+var errFetcherStopped = errors.New("arXiv fetcher stopped")
+
+// page is the result of fetching a single page of entries.
+type page struct {
+	records []opencdc.Record
+	err     error
+}
+
+// startFetcher starts fetching pages in a background goroutine, so the next
+// page is already on its way while the records of the current one are being
+// read. The fetcher stops when ctx is cancelled or when a page fails to be
+// fetched.
+func (s *Source) startFetcher(ctx context.Context) {
+	pages := make(chan page, s.config.PrefetchPages)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(pages)
+		s.runFetcher(ctx, pages)
+	}()
+
+	s.pages = pages
+}
+
+func (s *Source) runFetcher(ctx context.Context, pages chan<- page) {
+	for {
+		// Changes to emitted papers are checked between two polls
+		if s.reconcileDue() {
+			if err := s.reconcile(ctx, pages); err != nil {
+				return // context cancelled
+			}
+		}
+
+		// Wait for rate limiter
+		if err := s.limiter.Wait(ctx); err != nil {
+			return // context cancelled
+		}
+
+		records, err := s.fetchPage(ctx)
+		if err != nil && ctx.Err() != nil {
+			return // request was interrupted by cancellation
+		}
+
+		select {
+		case pages <- page{records: records, err: err}:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// nextPage returns the records of the next fetched page, blocking until one
+// is available.
+func (s *Source) nextPage(ctx context.Context) ([]opencdc.Record, error) {
+	select {
+	case p, ok := <-s.pages:
+		if !ok {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, errFetcherStopped
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+		sdk.Logger(ctx).Debug().Int("records", len(p.records)).Msg("received prefetched page")
+		return p.records, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package arxiv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// createPagingServer returns a server that responds with one entry per
+// request and counts the requests it received.
+func createPagingServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.%05dv1</id>
+    <title>Paper %d</title>
+    <summary>Summary</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-01T00:00:00Z</updated>
+  </entry>
+</feed>`, n, n)
+	}))
+}
+
+func TestFetcher_PrefetchesNextPage(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	server := createPagingServer(t, &requests)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"polling_period": "10ms",
+		"prefetch_pages": "2",
+	})
+
+	_, err := src.Read(ctx)
+	is.NoErr(err)
+
+	// the following pages are fetched without further calls to Read
+	deadline := time.Now().Add(time.Second)
+	for requests.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	is.True(requests.Load() >= 3)
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "2401.00002")
+}
+
+func TestFetcher_TeardownStopsWaitingFetcher(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	server := createPagingServer(t, &requests)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"polling_period": "1h",
+	})
+
+	_, err := src.Read(ctx)
+	is.NoErr(err)
+
+	// the fetcher is now waiting for the rate limiter for an hour
+	done := make(chan error)
+	go func() { done <- src.Teardown(ctx) }()
+
+	select {
+	case err := <-done:
+		is.NoErr(err)
+	case <-time.After(time.Second):
+		t.Fatal("teardown did not stop the fetcher")
+	}
+	is.Equal(requests.Load(), int32(1))
+}
+
+func TestFetcher_ReadRespectsContext(t *testing.T) {
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	// registered before the source, so the source is torn down first and
+	// cancels the blocked request
+	t.Cleanup(server.Close)
+
+	src := openTestSource(context.Background(), t, server.URL, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), context.DeadlineExceeded.Error()))
+}
+
+func TestFetcher_TeardownCancelsInFlightRequest(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL, nil)
+	<-started
+
+	teardownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	is.NoErr(src.Teardown(teardownCtx))
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request was not cancelled")
+	}
+}
@@ -0,0 +1,311 @@
+package arxiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/hamba/avro/v2"
+)
+
+// FieldMappingConfig controls how the fields produced for an arXiv entry are
+// laid out in the resulting record. Fields are always referenced by their
+// original name (e.g. "abstract" or "arxiv.published"), regardless of any
+// renames.
+type FieldMappingConfig struct {
+	// Rename maps original field names to the names they should have in the
+	// output record (e.g. field_mapping.rename.abstract: summary). Targets must
+	// not be renamed themselves or collide with fields that are not dropped.
+	Rename map[string]string `json:"rename"`
+	// Drop is a list of fields removed from the output record.
+	Drop []string `json:"drop"`
+	// ToMetadata is a list of payload fields moved into the record metadata.
+	// Non-string values are JSON encoded.
+	ToMetadata []string `json:"to_metadata"`
+	// ToPayload is a list of metadata fields moved into the record payload.
+	ToPayload []string `json:"to_payload"`
+	// Key is the list of fields used to build the record key. A single field
+	// produces a raw key, multiple fields produce a structured key. The
+	// default versionless ID keeps the key of a paper stable across versions,
+	// so creates, updates and deletes of a paper share it. Fields that can be
+	// absent or null, like doi or pdf_url, can't be used.
+	Key []string `json:"key" default:"arxiv_base_id"`
+}
+
+func (c FieldMappingConfig) Validate() error {
+	if len(c.Key) == 0 {
+		return fmt.Errorf("field_mapping.key must contain at least one field")
+	}
+
+	toMetadata := make(map[string]bool, len(c.ToMetadata))
+	for _, f := range c.ToMetadata {
+		toMetadata[f] = true
+	}
+	for _, f := range c.ToPayload {
+		if toMetadata[f] {
+			return fmt.Errorf("field %q can't be moved to both payload and metadata", f)
+		}
+	}
+
+	existing := knownFields()
+	for _, option := range []struct {
+		name   string
+		fields []string
+	}{
+		{"rename", sortedKeys(c.Rename)},
+		{"drop", c.Drop},
+		{"to_metadata", c.ToMetadata},
+		{"to_payload", c.ToPayload},
+	} {
+		for _, f := range option.fields {
+			if !existing[f] {
+				return fmt.Errorf("field_mapping.%s: unknown field %q", option.name, f)
+			}
+		}
+	}
+
+	drop := toSet(c.Drop)
+	optional := optionalFields()
+	for _, f := range c.Key {
+		switch {
+		case !existing[f]:
+			return fmt.Errorf("field_mapping.key: unknown field %q", f)
+		case drop[f]:
+			return fmt.Errorf("field_mapping.key: field %q is dropped", f)
+		case optional[f]:
+			return fmt.Errorf("field_mapping.key: field %q can be absent or null", f)
+		}
+	}
+
+	targets := make(map[string]string, len(c.Rename))
+	for _, from := range sortedKeys(c.Rename) {
+		to := c.Rename[from]
+		if to == "" {
+			return fmt.Errorf("field_mapping.rename.%s must not be empty", from)
+		}
+		if other, ok := targets[to]; ok {
+			return fmt.Errorf("fields %q and %q are both renamed to %q", other, from, to)
+		}
+		if _, ok := c.Rename[to]; ok {
+			return fmt.Errorf("field %q is renamed to %q, which is renamed itself", from, to)
+		}
+		if existing[to] && !drop[to] {
+			return fmt.Errorf("field %q can't be renamed to existing field %q, drop it first", from, to)
+		}
+		targets[to] = from
+	}
+
+	return nil
+}
+
+// fieldMapper applies a FieldMappingConfig to the payload and metadata
+// produced for a single entry.
+type fieldMapper struct {
+	rename     map[string]string
+	drop       map[string]bool
+	toMetadata map[string]bool
+	toPayload  map[string]bool
+	key        []string
+}
+
+func newFieldMapper(c FieldMappingConfig) *fieldMapper {
+	return &fieldMapper{
+		rename:     c.Rename,
+		drop:       toSet(c.Drop),
+		toMetadata: toSet(c.ToMetadata),
+		toPayload:  toSet(c.ToPayload),
+		key:        c.Key,
+	}
+}
+
+// apply builds the record key and rearranges data and meta in place according
+// to the mapping configuration.
+func (m *fieldMapper) apply(data map[string]interface{}, meta opencdc.Metadata) (opencdc.Data, error) {
+	key, err := m.buildKey(data, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range m.drop {
+		delete(data, name)
+		delete(meta, name)
+	}
+
+	for name := range m.toMetadata {
+		v, ok := data[name]
+		if !ok {
+			continue
+		}
+		s, err := metadataValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move field %q to metadata: %w", name, err)
+		}
+		delete(data, name)
+		meta[name] = s
+	}
+
+	for name := range m.toPayload {
+		v, ok := meta[name]
+		if !ok {
+			continue
+		}
+		delete(meta, name)
+		data[name] = v
+	}
+
+	// renamed values are taken out before any is put back, so the result
+	// doesn't depend on the order of the renames
+	renamedData := make(map[string]interface{}, len(m.rename))
+	renamedMeta := make(map[string]string, len(m.rename))
+	for from := range m.rename {
+		if v, ok := data[from]; ok {
+			delete(data, from)
+			renamedData[from] = v
+		}
+		if v, ok := meta[from]; ok {
+			delete(meta, from)
+			renamedMeta[from] = v
+		}
+	}
+	for from, v := range renamedData {
+		data[m.rename[from]] = v
+	}
+	for from, v := range renamedMeta {
+		meta[m.rename[from]] = v
+	}
+
+	return key, nil
+}
+
+// applySchema applies the mapping to the fields of the typed payload schema.
+// Fields moved into the payload from metadata are added as optional strings.
+func (m *fieldMapper) applySchema(fields []schemaField) []schemaField {
+	out := make([]schemaField, 0, len(fields)+len(m.toPayload))
+	for _, f := range fields {
+		if m.drop[f.name] || m.toMetadata[f.name] {
+			continue
+		}
+		out = append(out, f)
+	}
+
+	for _, name := range sortedKeys(m.toPayload) {
+		if m.drop[name] {
+			continue
+		}
+		out = append(out, schemaField{
+			name:     name,
+			typ:      avro.NewPrimitiveSchema(avro.String, nil),
+			optional: true,
+		})
+	}
+
+	for i, f := range out {
+		if to, ok := m.rename[f.name]; ok {
+			out[i].name = to
+		}
+	}
+	return out
+}
+
+func (m *fieldMapper) buildKey(data map[string]interface{}, meta opencdc.Metadata) (opencdc.Data, error) {
+	lookup := func(name string) (interface{}, bool) {
+		if v, ok := data[name]; ok {
+			return v, true
+		}
+		v, ok := meta[name]
+		return v, ok
+	}
+
+	if len(m.key) == 1 {
+		v, ok := lookup(m.key[0])
+		if !ok {
+			return nil, fmt.Errorf("key field %q not found", m.key[0])
+		}
+		s, err := metadataValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode key field %q: %w", m.key[0], err)
+		}
+		return opencdc.RawData(s), nil
+	}
+
+	key := make(opencdc.StructuredData, len(m.key))
+	for _, name := range m.key {
+		v, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("key field %q not found", name)
+		}
+		if to, ok := m.rename[name]; ok {
+			name = to
+		}
+		key[name] = v
+	}
+	return key, nil
+}
+
+// metadataValue converts a payload value into a metadata string.
+func metadataValue(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode value: %w", err)
+	}
+	return string(b), nil
+}
+
+// metadataFields are the metadata fields set by the source, which can be
+// referenced in the mapping like payload fields.
+var metadataFields = []string{
+	"arxiv.id",
+	"arxiv.title",
+	"arxiv.published",
+	"arxiv.updated",
+	"arxiv.version",
+	"arxiv.primary_category",
+	"arxiv.withdrawn",
+	"arxiv.reconciled",
+	"arxiv.changed_fields",
+	opencdc.MetadataReadAt,
+	opencdc.MetadataCreatedAt,
+}
+
+// knownFields returns the names of all payload and metadata fields produced
+// by the source.
+func knownFields() map[string]bool {
+	known := toSet(metadataFields)
+	for _, f := range paperSchemaFields() {
+		known[f.name] = true
+	}
+	return known
+}
+
+// optionalFields returns the names of the payload and metadata fields that
+// can be absent or null, which can't be part of the key.
+func optionalFields() map[string]bool {
+	optional := toSet([]string{"arxiv.withdrawn", "arxiv.reconciled", "arxiv.changed_fields"})
+	for _, f := range paperSchemaFields() {
+		if f.optional {
+			optional[f.name] = true
+		}
+	}
+	return optional
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,154 @@
+package arxiv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+)
+
+func TestFieldMapping_RenameDropMove(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, mockArxivResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"field_mapping.rename.abstract":    "summary",
+		"field_mapping.rename.arxiv.title": "arxiv.name",
+		"field_mapping.drop":               "entry_url,arxiv.published",
+		"field_mapping.to_metadata":        "authors",
+		"field_mapping.to_payload":         "arxiv.id",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(data["summary"], "Sample Summary")
+	_, ok = data["abstract"]
+	is.True(!ok)
+	_, ok = data["entry_url"]
+	is.True(!ok)
+	_, ok = data["authors"]
+	is.True(!ok)
+	is.Equal(data["arxiv.id"], "2401.12345v1")
+
+	is.Equal(rec.Metadata["authors"], `["Author One"]`)
+	is.Equal(rec.Metadata["arxiv.name"], "Sample Title")
+	_, ok = rec.Metadata["arxiv.published"]
+	is.True(!ok)
+	_, ok = rec.Metadata["arxiv.id"]
+	is.True(!ok)
+}
+
+func TestFieldMapping_StructuredKey(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, mockArxivResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"field_mapping.key":              "arxiv_id,published",
+		"field_mapping.rename.arxiv_id":  "id",
+		"sdk.schema.extract.key.enabled": "false",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	key, ok := rec.Key.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(key, opencdc.StructuredData{
+		"id":        "2401.12345v1",
+		"published": "2025-06-01T00:00:00Z",
+	})
+}
+
+func TestFieldMapping_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr string
+	}{
+		{
+			name: "field moved both ways",
+			config: map[string]string{
+				"field_mapping.to_metadata": "title",
+				"field_mapping.to_payload":  "title",
+			},
+			wantErr: `field "title" can't be moved to both payload and metadata`,
+		},
+		{
+			name: "rename collision",
+			config: map[string]string{
+				"field_mapping.rename.title":    "name",
+				"field_mapping.rename.abstract": "name",
+			},
+			wantErr: `are both renamed to "name"`,
+		},
+		{
+			name: "rename chain",
+			config: map[string]string{
+				"field_mapping.rename.abstract": "title",
+				"field_mapping.rename.title":    "headline",
+			},
+			wantErr: `field "abstract" is renamed to "title", which is renamed itself`,
+		},
+		{
+			name:    "unknown dropped field",
+			config:  map[string]string{"field_mapping.drop": "abstracts"},
+			wantErr: `field_mapping.drop: unknown field "abstracts"`,
+		},
+		{
+			name:    "unknown key field",
+			config:  map[string]string{"field_mapping.key": "arxiv_ID"},
+			wantErr: `field_mapping.key: unknown field "arxiv_ID"`,
+		},
+		{
+			name: "dropped key field",
+			config: map[string]string{
+				"field_mapping.key":  "arxiv_id",
+				"field_mapping.drop": "arxiv_id",
+			},
+			wantErr: `field_mapping.key: field "arxiv_id" is dropped`,
+		},
+		{
+			name:    "optional key field",
+			config:  map[string]string{"field_mapping.key": "arxiv_base_id,doi"},
+			wantErr: `field_mapping.key: field "doi" can be absent or null`,
+		},
+		{
+			name:    "metadata key field set only for some papers",
+			config:  map[string]string{"field_mapping.key": "arxiv.withdrawn"},
+			wantErr: `field_mapping.key: field "arxiv.withdrawn" can be absent or null`,
+		},
+		{
+			name: "rename onto existing field",
+			config: map[string]string{
+				"field_mapping.rename.abstract": "title",
+			},
+			wantErr: `field "abstract" can't be renamed to existing field "title"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+			src := arxiv.NewSource()
+
+			tt.config["search_query"] = "AI"
+			err := sdk.Util.ParseConfig(ctx, tt.config, src.Config(), arxiv.Connector.NewSpecification().SourceParams)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tt.wantErr))
+		})
+	}
+}
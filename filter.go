@@ -0,0 +1,203 @@
+package arxiv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FilterConfig contains filters evaluated on every parsed entry, for
+// conditions that can't be expressed in the arXiv query syntax. Entries that
+// don't pass all filters are skipped. Zero values disable a filter.
+type FilterConfig struct {
+	// PublishedWithin only keeps entries first published within this duration
+	// before the time they are fetched.
+	PublishedWithin time.Duration `json:"published_within" default:"0s"`
+	// MinAuthors is the minimum number of authors.
+	MinAuthors int `json:"min_authors" default:"0"`
+	// MaxAuthors is the maximum number of authors.
+	MaxAuthors int `json:"max_authors" default:"0"`
+	// MinAbstractLength is the minimum length of the abstract in characters.
+	MinAbstractLength int `json:"min_abstract_length" default:"0"`
+	// MaxAbstractLength is the maximum length of the abstract in characters.
+	MaxAbstractLength int `json:"max_abstract_length" default:"0"`
+	// CommentPattern is a regular expression the author comment must match,
+	// e.g. "(?i)accepted at neurips". Entries without a comment never match.
+	CommentPattern string `json:"comment_pattern"`
+	// HasDOI only keeps entries with a journal DOI.
+	HasDOI bool `json:"has_doi" default:"false"`
+	// PeerReviewed only keeps entries with a journal reference or a comment
+	// stating that the paper was accepted or published.
+	PeerReviewed bool `json:"peer_reviewed" default:"false"`
+	// PrimaryCategoryOnly only keeps entries whose primary category is one of
+	// the categories in search_query, dropping cross-lists. Categories
+	// excluded with ANDNOT are not counted.
+	PrimaryCategoryOnly bool `json:"primary_category_only" default:"false"`
+}
+
+func (c FilterConfig) Validate(searchQuery string) error {
+	if c.PublishedWithin < 0 {
+		return fmt.Errorf("filter.published_within must not be negative")
+	}
+	if c.MinAuthors < 0 || c.MaxAuthors < 0 {
+		return fmt.Errorf("filter author counts must not be negative")
+	}
+	if c.MaxAuthors > 0 && c.MinAuthors > c.MaxAuthors {
+		return fmt.Errorf("filter.min_authors must not be greater than filter.max_authors")
+	}
+	if c.MinAbstractLength < 0 || c.MaxAbstractLength < 0 {
+		return fmt.Errorf("filter abstract lengths must not be negative")
+	}
+	if c.MaxAbstractLength > 0 && c.MinAbstractLength > c.MaxAbstractLength {
+		return fmt.Errorf("filter.min_abstract_length must not be greater than filter.max_abstract_length")
+	}
+	if _, err := regexp.Compile(c.CommentPattern); err != nil {
+		return fmt.Errorf("invalid filter.comment_pattern: %w", err)
+	}
+	if c.PrimaryCategoryOnly && len(queryCategories(searchQuery)) == 0 {
+		return fmt.Errorf("filter.primary_category_only requires a cat: term in search_query")
+	}
+	return nil
+}
+
+// entryFilter is a single named condition an entry has to satisfy.
+type entryFilter struct {
+	name string
+	keep func(e *ArxivEntry) bool
+}
+
+// entryFilters is the list of filters enabled by a FilterConfig.
+type entryFilters []entryFilter
+
+// newEntryFilters returns the filters enabled in c. filterLast24Hours is the
+// legacy filter_last_24_hours option.
+func newEntryFilters(c FilterConfig, filterLast24Hours bool, searchQuery string, now func() time.Time) (entryFilters, error) {
+	var filters entryFilters
+
+	within := c.PublishedWithin
+	if filterLast24Hours && (within == 0 || within > 24*time.Hour) {
+		within = 24 * time.Hour
+	}
+	if within > 0 {
+		filters = append(filters, entryFilter{"published_within", func(e *ArxivEntry) bool {
+			return !e.Published.Before(now().Add(-within))
+		}})
+	}
+	if c.MinAuthors > 0 {
+		filters = append(filters, entryFilter{"min_authors", func(e *ArxivEntry) bool {
+			return len(e.Authors) >= c.MinAuthors
+		}})
+	}
+	if c.MaxAuthors > 0 {
+		filters = append(filters, entryFilter{"max_authors", func(e *ArxivEntry) bool {
+			return len(e.Authors) <= c.MaxAuthors
+		}})
+	}
+	if c.MinAbstractLength > 0 {
+		filters = append(filters, entryFilter{"min_abstract_length", func(e *ArxivEntry) bool {
+			return abstractLength(e) >= c.MinAbstractLength
+		}})
+	}
+	if c.MaxAbstractLength > 0 {
+		filters = append(filters, entryFilter{"max_abstract_length", func(e *ArxivEntry) bool {
+			return abstractLength(e) <= c.MaxAbstractLength
+		}})
+	}
+	if c.CommentPattern != "" {
+		re, err := regexp.Compile(c.CommentPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter.comment_pattern: %w", err)
+		}
+		filters = append(filters, entryFilter{"comment_pattern", func(e *ArxivEntry) bool {
+			return e.Comment != "" && re.MatchString(e.Comment)
+		}})
+	}
+	if c.HasDOI {
+		filters = append(filters, entryFilter{"has_doi", func(e *ArxivEntry) bool {
+			return strings.TrimSpace(e.DOI) != ""
+		}})
+	}
+	if c.PeerReviewed {
+		filters = append(filters, entryFilter{"peer_reviewed", peerReviewed})
+	}
+	if c.PrimaryCategoryOnly {
+		categories := queryCategories(searchQuery)
+		filters = append(filters, entryFilter{"primary_category_only", func(e *ArxivEntry) bool {
+			primary := e.PrimaryCategoryTerm()
+			for _, c := range categories {
+				if matchQueryCategory(primary, c) {
+					return true
+				}
+			}
+			return false
+		}})
+	}
+
+	return filters, nil
+}
+
+// apply returns the name of the first filter rejecting the entry, or an
+// empty string if the entry passes all filters.
+func (f entryFilters) apply(e *ArxivEntry) string {
+	for _, filter := range f {
+		if !filter.keep(e) {
+			return filter.name
+		}
+	}
+	return ""
+}
+
+func abstractLength(e *ArxivEntry) int {
+	return utf8.RuneCountInString(strings.TrimSpace(e.Summary))
+}
+
+// queryTokenRegex splits a search query into parentheses and terms, keeping
+// quoted values together with their field prefix.
+var queryTokenRegex = regexp.MustCompile(`[()]|[^\s()"+]*"[^"]*"|[^\s()"+]+`)
+
+// queryCategories returns the categories referenced with cat: in a query,
+// leaving out the categories that are excluded with ANDNOT.
+func queryCategories(query string) []string {
+	var out []string
+	// negated holds for every open group whether it is excluded
+	negated := []bool{false}
+	negateNext := false
+	for _, tok := range queryTokenRegex.FindAllString(query, -1) {
+		excluded := negated[len(negated)-1] != negateNext
+		switch tok {
+		case "(":
+			negated = append(negated, excluded)
+		case ")":
+			if len(negated) > 1 {
+				negated = negated[:len(negated)-1]
+			}
+		case "ANDNOT":
+			negateNext = true
+			continue
+		case "AND", "OR":
+			continue
+		default:
+			if m := categoryQueryRegex.FindStringSubmatch(tok); m != nil && !excluded {
+				out = append(out, m[1])
+			}
+		}
+		negateNext = false
+	}
+	return out
+}
+
+// matchQueryCategory reports whether term matches a category from a query,
+// which may be an archive wildcard like cs.* or a legacy alias.
+func matchQueryCategory(term, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(term, prefix)
+	}
+	if a, ok := LookupCategory(term); ok {
+		if b, ok := LookupCategory(pattern); ok {
+			return a.ID == b.ID
+		}
+	}
+	return term == pattern
+}
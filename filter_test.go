@@ -0,0 +1,181 @@
+package arxiv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func filterCorpus() []arxivtest.Paper {
+	published := time.Now().UTC().Add(-time.Hour)
+	return []arxivtest.Paper{
+		{
+			ID:         "2401.00001",
+			Summary:    "A short abstract.",
+			Authors:    []string{"A"},
+			Published:  published,
+			Categories: []string{"cs.LG"},
+			Comment:    "Accepted at NeurIPS 2024",
+		},
+		{
+			ID:         "2401.00002",
+			Summary:    strings.Repeat("long abstract ", 20),
+			Authors:    []string{"A", "B", "C"},
+			Published:  published,
+			Categories: []string{"stat.ML", "cs.LG"},
+			DOI:        "10.1000/abc",
+		},
+		{
+			ID:         "2401.00003",
+			Summary:    strings.Repeat("medium ", 5),
+			Authors:    []string{"A", "B"},
+			Published:  published.Add(-48 * time.Hour),
+			Categories: []string{"cs.LG"},
+			Comment:    "12 pages",
+			DOI:        "10.1000/def",
+		},
+	}
+}
+
+func TestSource_Filters(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		want   []string
+	}{
+		{"no filters", nil, []string{"2401.00001", "2401.00002", "2401.00003"}},
+		{"min authors", map[string]string{"filter.min_authors": "2"}, []string{"2401.00002", "2401.00003"}},
+		{"max authors", map[string]string{"filter.max_authors": "2"}, []string{"2401.00001", "2401.00003"}},
+		{"abstract length", map[string]string{"filter.min_abstract_length": "20", "filter.max_abstract_length": "100"}, []string{"2401.00003"}},
+		{"comment pattern", map[string]string{"filter.comment_pattern": "(?i)accepted at neurips"}, []string{"2401.00001"}},
+		{"has doi", map[string]string{"filter.has_doi": "true"}, []string{"2401.00002", "2401.00003"}},
+		{"primary category only", map[string]string{"filter.primary_category_only": "true"}, []string{"2401.00001", "2401.00003"}},
+		{"published within", map[string]string{"filter.published_within": "24h"}, []string{"2401.00001", "2401.00002"}},
+		{"legacy last 24 hours", map[string]string{"filter_last_24_hours": "true"}, []string{"2401.00001", "2401.00002"}},
+		{"combined", map[string]string{"filter.has_doi": "true", "filter.published_within": "24h"}, []string{"2401.00002"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+
+			server := arxivtest.NewServer(filterCorpus())
+			t.Cleanup(server.Close)
+
+			cfg := map[string]string{
+				"search_query": "cat:cs.LG",
+				"sort_by":      "relevance",
+			}
+			for k, v := range tt.config {
+				cfg[k] = v
+			}
+			src := openTestSource(ctx, t, server.URL(), cfg)
+
+			var got []string
+			for {
+				rec, err := src.Read(ctx)
+				if err == sdk.ErrBackoffRetry {
+					break
+				}
+				is.NoErr(err)
+				got = append(got, string(rec.Key.Bytes()))
+			}
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestSource_FilterPositions(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(filterCorpus())
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":   "cat:cs.LG",
+		"sort_by":        "relevance",
+		"filter.has_doi": "true",
+	})
+
+	// filtered entries still count towards the position
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Position), "1")
+	rec, err = src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Position), "2")
+}
+
+func TestSource_FilterPrimaryCategoryNegated(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	response := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.00001v1</id>
+    <title>Cross-listed to cs.AI</title>
+    <summary>An abstract.</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-01T00:00:00Z</updated>
+    <arxiv:primary_category term="cs.LG" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.LG" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.AI" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+  <entry>
+    <id>http://arxiv.org/abs/2401.00002v1</id>
+    <title>Primary cs.AI</title>
+    <summary>An abstract.</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-01T00:00:00Z</updated>
+    <arxiv:primary_category term="cs.AI" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.AI" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>`
+
+	server := createMockArxivServer(t, response)
+	defer server.Close()
+
+	// excluded categories don't count as primary categories of the query
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"search_query":                 "cat:cs.AI ANDNOT cat:cs.LG",
+		"filter.primary_category_only": "true",
+	})
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(rec.Key, opencdc.RawData("2401.00002"))
+}
+
+func TestFilterConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		errMsg string
+	}{
+		{"invalid comment pattern", map[string]string{"filter.comment_pattern": "("}, "invalid filter.comment_pattern"},
+		{"negative authors", map[string]string{"filter.min_authors": "-1"}, "filter author counts must not be negative"},
+		{"min above max authors", map[string]string{"filter.min_authors": "3", "filter.max_authors": "2"}, "filter.min_authors must not be greater than filter.max_authors"},
+		{"min above max abstract", map[string]string{"filter.min_abstract_length": "30", "filter.max_abstract_length": "20"}, "filter.min_abstract_length must not be greater than filter.max_abstract_length"},
+		{"primary category without cat", map[string]string{"filter.primary_category_only": "true"}, "filter.primary_category_only requires a cat: term in search_query"},
+		{"primary category with negated cat", map[string]string{"filter.primary_category_only": "true", "search_query": "all:transformers ANDNOT (cat:cs.LG OR cat:cs.CV)"}, "filter.primary_category_only requires a cat: term in search_query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+
+			src := arxiv.NewSource()
+			err := configureTestSource(ctx, src, "http://localhost", tt.config)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tt.errMsg))
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package arxiv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	FixturesModeRecord = "record"
+	FixturesModeReplay = "replay"
+)
+
+var errFixtureNotFound = errors.New("no fixture recorded for request")
+
+// FixturesConfig configures recording and replaying of HTTP fixtures.
+type FixturesConfig struct {
+	// Mode is either empty (disabled), record (store every request/response
+	// pair in Dir) or replay (serve responses from Dir without network access).
+	Mode string `json:"mode"`
+	// Dir is the directory containing the fixtures.
+	Dir string `json:"dir"`
+}
+
+func (c FixturesConfig) Validate() error {
+	switch c.Mode {
+	case "":
+		return nil
+	case FixturesModeRecord, FixturesModeReplay:
+		if c.Dir == "" {
+			return fmt.Errorf("fixtures.dir is required when fixtures.mode is set")
+		}
+		return nil
+	default:
+		return fmt.Errorf("fixtures.mode must be either record or replay")
+	}
+}
+
+// Fixture is a recorded request/response pair.
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+type FixtureRequest struct {
+	Method string `json:"method"`
+	// URI is the request path and query, the host is not part of the fixture
+	// so fixtures can be replayed against any base URL.
+	URI string `json:"uri"`
+}
+
+type FixtureResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// FixtureFileName returns the file name under which the fixture for the
+// request with the given method and URI (path and query) is stored.
+func FixtureFileName(method, uri string) string {
+	sum := sha256.Sum256([]byte(method + " " + uri))
+	return hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// WriteFixture stores a fixture in dir.
+func WriteFixture(dir string, f Fixture) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	path := filepath.Join(dir, FixtureFileName(f.Request.Method, f.Request.URI))
+	if err := writeFileAtomic(path, b); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// NewRecordingTransport returns a transport that forwards requests to base
+// and stores every request/response pair as a fixture in dir. Responses
+// larger than maxSize bytes are rejected without being recorded, 0 disables
+// the limit.
+func NewRecordingTransport(base http.RoundTripper, dir string, maxSize int64) http.RoundTripper {
+	return &recordingTransport{base: base, dir: dir, maxSize: maxSize}
+}
+
+// NewReplayTransport returns a transport that serves responses from the
+// fixtures in dir and never touches the network.
+func NewReplayTransport(dir string) http.RoundTripper {
+	return &replayTransport{dir: dir}
+}
+
+type recordingTransport struct {
+	base    http.RoundTripper
+	dir     string
+	maxSize int64
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors are wrapped by the http.Client
+	}
+	defer resp.Body.Close()
+
+	body, err := readAllLimited(resp.Body, t.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		headers[k] = resp.Header.Get(k)
+	}
+	err = WriteFixture(t.dir, Fixture{
+		Request: FixtureRequest{
+			Method: req.Method,
+			URI:    req.URL.RequestURI(),
+		},
+		Response: FixtureResponse{
+			Status:  resp.StatusCode,
+			Headers: headers,
+			Body:    string(body),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (t *recordingTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if c, ok := t.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	uri := req.URL.RequestURI()
+	b, err := os.ReadFile(filepath.Join(t.dir, FixtureFileName(req.Method, uri)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", errFixtureNotFound, req.Method, uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+
+	header := make(http.Header, len(f.Response.Headers))
+	for k, v := range f.Response.Headers {
+		header.Set(k, v)
+	}
+	// the recorded body is always stored decoded
+	header.Del("Content-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(f.Response.Body)))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Response.Status, http.StatusText(f.Response.Status)),
+		StatusCode:    f.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(f.Response.Body))),
+		ContentLength: int64(len(f.Response.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package arxiv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+)
+
+func TestFixtures_ReplayMultiplePages(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	src := openTestSource(ctx, t, "http://export.arxiv.org/api/query", map[string]string{
+		"search_query":   "cat:cs.LG",
+		"max_results":    "2",
+		"polling_period": "1ms",
+		"fixtures.mode":  "replay",
+		"fixtures.dir":   "testdata/fixtures/cs-lg",
+	})
+
+	var keys []string
+	for range 4 {
+		rec, err := src.Read(ctx)
+		is.NoErr(err)
+		keys = append(keys, string(rec.Key.Bytes()))
+	}
+	is.Equal(keys, []string{"2406.01234", "2406.01200", "2406.01111", "2406.01002"})
+
+	// the last recorded page is empty
+	_, err := src.Read(ctx)
+	is.Equal(err, sdk.ErrBackoffRetry)
+}
+
+func TestFixtures_RecordAndReplay(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") != "0" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "Rate exceeded.")
+			return
+		}
+		fmt.Fprintln(w, mockArxivResponse)
+	}))
+
+	record := map[string]string{
+		"polling_period": "1ms",
+		"fixtures.mode":  "record",
+		"fixtures.dir":   dir,
+	}
+	src := openTestSource(ctx, t, server.URL+"/api/query", record)
+	_, err := src.Read(ctx)
+	is.NoErr(err)
+	_, err = src.Read(ctx)
+	is.True(strings.Contains(err.Error(), "arXiv API returned status 503"))
+	is.NoErr(src.Teardown(ctx))
+	server.Close()
+
+	files, err := os.ReadDir(dir)
+	is.NoErr(err)
+	is.Equal(len(files), 2)
+
+	// fixtures don't depend on the host, so they replay against any base URL
+	replay := map[string]string{
+		"polling_period": "1ms",
+		"fixtures.mode":  "replay",
+		"fixtures.dir":   dir,
+	}
+	src = openTestSource(ctx, t, "http://arxiv.invalid/api/query", replay)
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "2401.12345")
+	_, err = src.Read(ctx)
+	is.True(strings.Contains(err.Error(), "arXiv API returned status 503: Rate exceeded."))
+}
+
+func TestFixtures_RecordSizeLimit(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat(" ", 70000))
+	}))
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"fixtures.mode":          "record",
+		"fixtures.dir":           dir,
+		"http.max_response_size": "65536",
+	})
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "response exceeds maximum size of 65536 bytes"))
+
+	files, err := os.ReadDir(dir)
+	is.NoErr(err)
+	is.Equal(len(files), 0)
+}
+
+func TestFixtures_ReplayMissing(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	src := openTestSource(ctx, t, "http://export.arxiv.org/api/query", map[string]string{
+		"fixtures.mode": "replay",
+		"fixtures.dir":  t.TempDir(),
+	})
+
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "no fixture recorded for request"))
+}
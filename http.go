@@ -0,0 +1,177 @@
+package arxiv
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// HTTPConfig contains the settings of the HTTP client used to talk to the
+// arXiv API.
+type HTTPConfig struct {
+	// Timeout is the overall timeout of a single request, including reading
+	// the response body.
+	Timeout time.Duration `json:"timeout" default:"30s"`
+	// ConnectTimeout is the timeout for establishing a connection.
+	ConnectTimeout time.Duration `json:"connect_timeout" default:"10s"`
+	// ReadTimeout is the timeout for receiving the response headers after the
+	// request was sent.
+	ReadTimeout time.Duration `json:"read_timeout" default:"30s"`
+	// ProxyURL is the URL of the HTTP(S) proxy used for requests. If empty,
+	// the proxy is taken from the HTTP_PROXY/HTTPS_PROXY environment variables.
+	ProxyURL string `json:"proxy_url"`
+	// CACertFile is the path to a PEM encoded CA bundle used to verify the
+	// server certificate, in addition to the system roots.
+	CACertFile string `json:"ca_cert_file"`
+	// InsecureSkipVerify disables verification of the server certificate.
+	// Only use this for internal mirrors.
+	InsecureSkipVerify bool `json:"insecure_skip_verify" default:"false"`
+	// Headers are extra headers added to every request.
+	Headers map[string]string `json:"headers"`
+	// Compression requests gzip/deflate compressed responses from the server.
+	Compression bool `json:"compression" default:"true"`
+	// MaxResponseSize is the maximum size in bytes of a (decompressed)
+	// response body. Larger responses are rejected. 0 disables the limit.
+	MaxResponseSize int64 `json:"max_response_size" default:"67108864"`
+	// ContactEmail is included in the User-Agent, as arXiv asks of heavy
+	// users of its API.
+	ContactEmail string `json:"contact_email"`
+}
+
+func (c HTTPConfig) Validate() error {
+	if c.Timeout < 0 || c.ConnectTimeout < 0 || c.ReadTimeout < 0 {
+		return fmt.Errorf("http timeouts must not be negative")
+	}
+	if c.MaxResponseSize < 0 {
+		return fmt.Errorf("http.max_response_size must not be negative")
+	}
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("http.proxy_url %q is not a valid URL", c.ProxyURL)
+		}
+	}
+	if c.ContactEmail != "" && !strings.Contains(c.ContactEmail, "@") {
+		return fmt.Errorf("http.contact_email %q is not a valid email address", c.ContactEmail)
+	}
+	return nil
+}
+
+// UserAgent returns the User-Agent sent with every request.
+func (c HTTPConfig) UserAgent() string {
+	ua := fmt.Sprintf("conduit-connector-arxiv/%s (+https://github.com/raulb/conduit-connector-arxiv", version)
+	if c.ContactEmail != "" {
+		ua += "; mailto:" + c.ContactEmail
+	}
+	return ua + ")"
+}
+
+// newHTTPClient creates the HTTP client used to talk to the arXiv API. If a
+// cache directory is configured, responses are served through the cache. In
+// fixture replay mode the network is never used.
+func newHTTPClient(c HTTPConfig, cache CacheConfig, fixtures FixturesConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // explicitly enabled by the user
+	}
+	if c.CACertFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %q", c.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   c.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   c.ConnectTimeout,
+		ResponseHeaderTimeout: c.ReadTimeout,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   2,
+		ForceAttemptHTTP2:     true,
+		// Compression is negotiated by compressionTransport, so the size of
+		// the decompressed body can be limited.
+		DisableCompression: true,
+	}
+
+	var base http.RoundTripper = transport
+	if c.Compression {
+		base = &compressionTransport{base: transport}
+	}
+	switch fixtures.Mode {
+	case FixturesModeRecord:
+		base = NewRecordingTransport(base, fixtures.Dir, c.MaxResponseSize)
+	case FixturesModeReplay:
+		base = NewReplayTransport(fixtures.Dir)
+	}
+	if cache.Dir != "" {
+		ct, err := newCacheTransport(base, cache, c.MaxResponseSize)
+		if err != nil {
+			return nil, err
+		}
+		base = ct
+	}
+
+	headers := make(http.Header, len(c.Headers)+1)
+	headers.Set("User-Agent", c.UserAgent())
+	for k, v := range c.Headers {
+		headers.Set(k, v)
+	}
+
+	return &http.Client{
+		Timeout: c.Timeout,
+		Transport: &headerTransport{
+			base:    base,
+			headers: headers,
+		},
+	}, nil
+}
+
+// headerTransport adds a fixed set of headers to every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req) //nolint:wrapcheck // errors are wrapped by the http.Client
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport.
+func (t *headerTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if c, ok := t.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
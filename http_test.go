@@ -0,0 +1,97 @@
+package arxiv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestHTTP_UserAgentAndHeaders(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requests <- r:
+		default:
+		}
+		fmt.Fprintln(w, mockArxivResponse)
+	}))
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"http.contact_email":     "ops@example.com",
+		"http.headers.X-Team-ID": "research",
+	})
+
+	_, err := src.Read(ctx)
+	is.NoErr(err)
+
+	req := <-requests
+	is.True(strings.HasPrefix(req.UserAgent(), "conduit-connector-arxiv/"))
+	is.True(strings.Contains(req.UserAgent(), "mailto:ops@example.com"))
+	is.Equal(req.Header.Get("X-Team-ID"), "research")
+}
+
+func TestHTTP_Proxy(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case proxied <- r.URL.String():
+		default:
+		}
+		fmt.Fprintln(w, mockArxivResponse)
+	}))
+	defer proxy.Close()
+
+	src := openTestSource(ctx, t, "http://arxiv.invalid/api/query", map[string]string{
+		"http.proxy_url": proxy.URL,
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "2401.12345")
+	is.True(strings.HasPrefix(<-proxied, "http://arxiv.invalid/api/query?"))
+}
+
+func TestHTTP_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr string
+	}{
+		{
+			name:    "invalid proxy",
+			config:  map[string]string{"http.proxy_url": "not a url"},
+			wantErr: `http.proxy_url "not a url" is not a valid URL`,
+		},
+		{
+			name:    "invalid contact email",
+			config:  map[string]string{"http.contact_email": "ops"},
+			wantErr: `http.contact_email "ops" is not a valid email address`,
+		},
+		{
+			name:    "missing CA bundle",
+			config:  map[string]string{"http.ca_cert_file": "testdata/does-not-exist.pem"},
+			wantErr: "failed to read CA bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			err := openTestSourceErr(context.Background(), t, "http://localhost", tt.config)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tt.wantErr))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package arxiv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// newStyleID matches identifiers used since April 2007, e.g. 0704.0001
+	// or 2401.12345v2.
+	newStyleID = regexp.MustCompile(`^(\d{2})(\d{2})\.(\d{4,5})(?:v(\d+))?$`)
+	// oldStyleID matches identifiers used before April 2007, e.g.
+	// hep-th/9901001v1 or math.GT/0309136.
+	oldStyleID = regexp.MustCompile(`^([a-z]+(?:-[a-z]+)*)(?:\.([A-Z]{2}))?/(\d{2})(\d{2})(\d{3})(?:v(\d+))?$`)
+)
+
+// Identifier is a parsed arXiv identifier.
+type Identifier struct {
+	// Archive is the archive of an old-style identifier (e.g. hep-th), empty
+	// for new-style identifiers.
+	Archive string
+	// Subject is the optional subject class of an old-style identifier (e.g.
+	// GT in math.GT/0309136). It is not part of the canonical identifier.
+	Subject string
+	// Number is the sequence number including the year and month, e.g.
+	// 2401.12345 or 9901001.
+	Number string
+	// Version is the version of the paper, 0 if the identifier is
+	// unversioned.
+	Version int
+}
+
+// ParseIdentifier parses an arXiv identifier. Besides bare identifiers it
+// accepts identifiers prefixed with "arXiv:" and abs or pdf URLs, as used in
+// the id of API entries.
+func ParseIdentifier(s string) (Identifier, error) {
+	id := strings.TrimSpace(s)
+	for _, prefix := range []string{"/abs/", "/pdf/"} {
+		if i := strings.Index(id, prefix); i >= 0 {
+			id = strings.TrimSuffix(id[i+len(prefix):], ".pdf")
+			break
+		}
+	}
+	if len(id) > len("arxiv:") && strings.EqualFold(id[:len("arxiv:")], "arxiv:") {
+		id = id[len("arxiv:"):]
+	}
+
+	if m := newStyleID.FindStringSubmatch(id); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		// 5 digit sequence numbers are used since January 2015
+		if month < 1 || month > 12 || year < 7 || (year == 7 && month < 4) ||
+			(len(m[3]) == 5) != (year >= 15) {
+			return Identifier{}, fmt.Errorf("invalid arXiv identifier %q", s)
+		}
+		version, err := parseIDVersion(m[4])
+		if err != nil {
+			return Identifier{}, fmt.Errorf("invalid arXiv identifier %q: %w", s, err)
+		}
+		return Identifier{Number: m[1] + m[2] + "." + m[3], Version: version}, nil
+	}
+
+	if m := oldStyleID.FindStringSubmatch(id); m != nil {
+		year, _ := strconv.Atoi(m[3])
+		month, _ := strconv.Atoi(m[4])
+		// old-style identifiers were used from August 1991 to March 2007
+		if month < 1 || month > 12 || (year > 7 && year < 91) || (year == 7 && month > 3) {
+			return Identifier{}, fmt.Errorf("invalid arXiv identifier %q", s)
+		}
+		version, err := parseIDVersion(m[6])
+		if err != nil {
+			return Identifier{}, fmt.Errorf("invalid arXiv identifier %q: %w", s, err)
+		}
+		return Identifier{
+			Archive: m[1],
+			Subject: m[2],
+			Number:  m[3] + m[4] + m[5],
+			Version: version,
+		}, nil
+	}
+
+	return Identifier{}, fmt.Errorf("invalid arXiv identifier %q", s)
+}
+
+func parseIDVersion(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+// IsLegacy reports whether the identifier is an old-style identifier.
+func (id Identifier) IsLegacy() bool {
+	return id.Archive != ""
+}
+
+// Base returns the canonical identifier without version, e.g. 2401.12345 or
+// hep-th/9901001.
+func (id Identifier) Base() string {
+	if id.IsLegacy() {
+		return id.Archive + "/" + id.Number
+	}
+	return id.Number
+}
+
+// String returns the canonical identifier, including the version if known.
+func (id Identifier) String() string {
+	if id.Version > 0 {
+		return id.Base() + "v" + strconv.Itoa(id.Version)
+	}
+	return id.Base()
+}
+
+// WithVersion returns the identifier of the given version of the paper.
+func (id Identifier) WithVersion(version int) Identifier {
+	id.Version = version
+	return id
+}
+
+// arxivBaseURL is the base URL of the canonical links generated for papers.
+const arxivBaseURL = "https://arxiv.org"
+
+// AbsURL returns the URL of the abstract page. If the identifier has a
+// version, the URL points to that version.
+func (id Identifier) AbsURL() string {
+	return arxivBaseURL + "/abs/" + id.String()
+}
+
+// PDFURL returns the URL of the PDF. If the identifier has a version, the
+// URL points to that version.
+func (id Identifier) PDFURL() string {
+	return arxivBaseURL + "/pdf/" + id.String()
+}
+
+// HTMLURL returns the URL of the HTML rendition of the paper. If the
+// identifier has a version, the URL points to that version.
+func (id Identifier) HTMLURL() string {
+	return arxivBaseURL + "/html/" + id.String()
+}
+
+// ListingURL returns the URL of the monthly listing of the category the
+// paper was announced in.
+func (id Identifier) ListingURL(category string) string {
+	year, _ := strconv.Atoi(id.Number[:2])
+	if year >= 91 {
+		year += 1900
+	} else {
+		year += 2000
+	}
+	return fmt.Sprintf("%s/list/%s/%d-%s", arxivBaseURL, category, year, id.Number[2:4])
+}
+
+// DOI returns the DataCite DOI arXiv assigns to every paper. It always
+// refers to the paper as a whole, not a specific version.
+func (id Identifier) DOI() string {
+	return "10.48550/arXiv." + id.Base()
+}
@@ -0,0 +1,189 @@
+package arxiv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   arxiv.Identifier
+		str    string
+		base   string
+		legacy bool
+	}{
+		{"2401.12345", arxiv.Identifier{Number: "2401.12345"}, "2401.12345", "2401.12345", false},
+		{"2401.12345v2", arxiv.Identifier{Number: "2401.12345", Version: 2}, "2401.12345v2", "2401.12345", false},
+		{"0704.0001", arxiv.Identifier{Number: "0704.0001"}, "0704.0001", "0704.0001", false},
+		{"1412.9999v10", arxiv.Identifier{Number: "1412.9999", Version: 10}, "1412.9999v10", "1412.9999", false},
+		{"arXiv:1501.00001", arxiv.Identifier{Number: "1501.00001"}, "1501.00001", "1501.00001", false},
+		{"http://arxiv.org/abs/2401.12345v1", arxiv.Identifier{Number: "2401.12345", Version: 1}, "2401.12345v1", "2401.12345", false},
+		{"https://arxiv.org/pdf/2401.12345v3.pdf", arxiv.Identifier{Number: "2401.12345", Version: 3}, "2401.12345v3", "2401.12345", false},
+		{"hep-th/9901001", arxiv.Identifier{Archive: "hep-th", Number: "9901001"}, "hep-th/9901001", "hep-th/9901001", true},
+		{"http://arxiv.org/abs/hep-th/9901001v2", arxiv.Identifier{Archive: "hep-th", Number: "9901001", Version: 2}, "hep-th/9901001v2", "hep-th/9901001", true},
+		{"math.GT/0309136", arxiv.Identifier{Archive: "math", Subject: "GT", Number: "0309136"}, "math/0309136", "math/0309136", true},
+		{"cond-mat/0703999v1", arxiv.Identifier{Archive: "cond-mat", Number: "0703999", Version: 1}, "cond-mat/0703999v1", "cond-mat/0703999", true},
+		{"q-bio/0408001", arxiv.Identifier{Archive: "q-bio", Number: "0408001"}, "q-bio/0408001", "q-bio/0408001", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			is := is.New(t)
+
+			id, err := arxiv.ParseIdentifier(tt.in)
+			is.NoErr(err)
+			is.Equal(id, tt.want)
+			is.Equal(id.String(), tt.str)
+			is.Equal(id.Base(), tt.base)
+			is.Equal(id.IsLegacy(), tt.legacy)
+		})
+	}
+}
+
+func TestParseIdentifier_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"9901001",
+		"2401.123",
+		"2401.123456",
+		"1412.12345", // 5 digit numbers are only used since 2015
+		"1501.0001",
+		"0703.0001", // new-style identifiers start in April 2007
+		"2413.00001",
+		"2401.00001v0",
+		"hep-th/0704001", // old-style identifiers end in March 2007
+		"hep-th/9913001",
+		"HEP-TH/9901001",
+		"http://arxiv.org/api/errors#incorrect_id_format",
+	} {
+		t.Run(in, func(t *testing.T) {
+			is := is.New(t)
+			_, err := arxiv.ParseIdentifier(in)
+			is.True(err != nil)
+		})
+	}
+}
+
+func TestIdentifier_WithVersion(t *testing.T) {
+	is := is.New(t)
+
+	id, err := arxiv.ParseIdentifier("hep-th/9901001v1")
+	is.NoErr(err)
+	is.Equal(id.WithVersion(3).String(), "hep-th/9901001v3")
+	is.Equal(id.WithVersion(0).String(), "hep-th/9901001")
+}
+
+func TestSource_LegacyIdentifiers(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := arxivtest.NewServer([]arxivtest.Paper{
+		{ID: "hep-th/9901001", Version: 2, Published: published, Categories: []string{"hep-th"}},
+		{ID: "hep-ph/9901001", Published: published, Categories: []string{"hep-ph"}},
+	})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query": "cat:hep-th OR cat:hep-ph",
+		"sort_by":      "relevance",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "hep-th/9901001")
+	is.Equal(rec.Metadata["arxiv.id"], "hep-th/9901001v2")
+	is.Equal(rec.Metadata["arxiv.version"], "2")
+
+	rec, err = src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(string(rec.Key.Bytes()), "hep-ph/9901001")
+}
+
+func TestIdentifier_URLs(t *testing.T) {
+	is := is.New(t)
+
+	id, err := arxiv.ParseIdentifier("2401.12345v2")
+	is.NoErr(err)
+	is.Equal(id.AbsURL(), "https://arxiv.org/abs/2401.12345v2")
+	is.Equal(id.WithVersion(0).AbsURL(), "https://arxiv.org/abs/2401.12345")
+	is.Equal(id.PDFURL(), "https://arxiv.org/pdf/2401.12345v2")
+	is.Equal(id.HTMLURL(), "https://arxiv.org/html/2401.12345v2")
+	is.Equal(id.ListingURL("cs.LG"), "https://arxiv.org/list/cs.LG/2024-01")
+	is.Equal(id.DOI(), "10.48550/arXiv.2401.12345")
+
+	legacy, err := arxiv.ParseIdentifier("math.GT/0309136v1")
+	is.NoErr(err)
+	is.Equal(legacy.AbsURL(), "https://arxiv.org/abs/math/0309136v1")
+	is.Equal(legacy.ListingURL("math.GT"), "https://arxiv.org/list/math.GT/2003-09")
+	is.Equal(legacy.WithVersion(0).ListingURL("hep-th"), "https://arxiv.org/list/hep-th/2003-09")
+	is.Equal(legacy.DOI(), "10.48550/arXiv.math/0309136")
+
+	old, err := arxiv.ParseIdentifier("hep-th/9901001")
+	is.NoErr(err)
+	is.Equal(old.ListingURL("hep-th"), "https://arxiv.org/list/hep-th/1999-01")
+}
+
+func TestSource_CanonicalURLs(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer([]arxivtest.Paper{{
+		ID:         "2401.12345",
+		Version:    3,
+		Published:  time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"cs.LG"},
+		DOI:        "10.1000/xyz123",
+	}})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{"search_query": "cat:cs.LG"})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(data["arxiv_doi"], "10.48550/arXiv.2401.12345")
+	is.Equal(data["doi"], "10.1000/xyz123")
+	is.Equal(data["urls"], map[string]interface{}{
+		"abs":            "https://arxiv.org/abs/2401.12345",
+		"abs_versioned":  "https://arxiv.org/abs/2401.12345v3",
+		"pdf":            "https://arxiv.org/pdf/2401.12345",
+		"pdf_versioned":  "https://arxiv.org/pdf/2401.12345v3",
+		"html":           "https://arxiv.org/html/2401.12345",
+		"html_versioned": "https://arxiv.org/html/2401.12345v3",
+		"listing":        "https://arxiv.org/list/cs.LG/2024-01",
+	})
+}
+
+func TestSource_CanonicalURLsWithoutPDF(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, noPDFResponse)
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"include_pdf":                        "false",
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	out := decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["arxiv_doi"], "10.48550/arXiv.2401.54321")
+	is.Equal(out["doi"], nil)
+	urls := out["urls"].(map[string]interface{})
+	is.Equal(urls["abs_versioned"], "https://arxiv.org/abs/2401.54321v2")
+	is.Equal(urls["pdf"], nil)
+	is.Equal(urls["pdf_versioned"], nil)
+	is.Equal(urls["listing"], nil)
+}
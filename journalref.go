@@ -0,0 +1,299 @@
+package arxiv
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConfidenceHigh marks values extracted from a well-known format.
+	ConfidenceHigh = "high"
+	// ConfidenceLow marks values guessed from free text.
+	ConfidenceLow = "low"
+)
+
+// JournalRef is the structured form of the arxiv:journal_ref of an entry.
+type JournalRef struct {
+	Venue  string
+	Volume string
+	Issue  string
+	Pages  string
+	Year   int
+	// Confidence is ConfidenceHigh if the reference matched a known citation
+	// format and ConfidenceLow if venue and year were guessed.
+	Confidence string
+}
+
+const (
+	refYear  = `\(?(?P<year>(?:19|20)\d{2})\)?`
+	refPages = `(?P<pages>[A-Za-z]?\d+(?:\s*[-–]+\s*[A-Za-z]?\d+)?)`
+)
+
+var (
+	journalRefFormats = []*regexp.Regexp{
+		// Phys. Rev. D 99, 123456 (2019)
+		// J. Mach. Learn. Res. 15(1):1929-1958, 2014
+		regexp.MustCompile(`^(?P<venue>.*?[A-Za-z.)])\s*(?P<volume>\d+)\s*(?:\((?P<issue>\d+)\))?\s*[,:]\s*` + refPages + `\s*,?\s*` + refYear + `\.?$`),
+		// Astrophys.J. 600 (2004) 580-590
+		regexp.MustCompile(`^(?P<venue>.*?[A-Za-z.)])\s*(?P<volume>\d+)\s*\((?P<year>(?:19|20)\d{2})\)\s*,?\s*` + refPages + `\.?$`),
+	}
+	yearRegex = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+)
+
+// ParseJournalRef parses a journal reference like "Phys. Rev. D 99, 123456
+// (2019)". It returns false if no venue could be found.
+func ParseJournalRef(s string) (JournalRef, bool) {
+	s = collapseSpace(s)
+	if s == "" {
+		return JournalRef{}, false
+	}
+
+	for _, re := range journalRefFormats {
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+		ref := JournalRef{Confidence: ConfidenceHigh}
+		for i, name := range re.SubexpNames() {
+			switch name {
+			case "venue":
+				ref.Venue = strings.Trim(m[i], " ,;:")
+			case "volume":
+				ref.Volume = m[i]
+			case "issue":
+				ref.Issue = m[i]
+			case "pages":
+				ref.Pages = strings.ReplaceAll(strings.ReplaceAll(m[i], " ", ""), "–", "-")
+			case "year":
+				ref.Year, _ = strconv.Atoi(m[i])
+			}
+		}
+		if ref.Venue != "" {
+			return ref, true
+		}
+	}
+
+	// Fall back to everything before the first number as the venue, and the
+	// last year mentioned.
+	ref := JournalRef{Confidence: ConfidenceLow}
+	ref.Venue = strings.Trim(strings.TrimRight(s[:firstDigit(s)], " ,;:("), " ")
+	if years := yearRegex.FindAllString(s, -1); len(years) > 0 {
+		ref.Year, _ = strconv.Atoi(years[len(years)-1])
+	}
+	if ref.Venue == "" {
+		return JournalRef{}, false
+	}
+	return ref, true
+}
+
+func firstDigit(s string) int {
+	if i := strings.IndexAny(s, "0123456789"); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+const (
+	CommentStatusAccepted    = "accepted"
+	CommentStatusPublished   = "published"
+	CommentStatusSubmitted   = "submitted"
+	CommentStatusUnderReview = "under_review"
+	CommentStatusRejected    = "rejected"
+)
+
+// Comment is the structured form of the arxiv:comment of an entry.
+type Comment struct {
+	Pages   int
+	Figures int
+	Tables  int
+	// Status is the publication status mentioned in the comment, e.g.
+	// CommentStatusAccepted for "accepted at ICML 2024".
+	Status    string
+	Venue     string
+	VenueYear int
+	// Confidence is ConfidenceHigh if the status was stated explicitly (e.g.
+	// "accepted at") and ConfidenceLow if it was guessed from a venue name
+	// followed by a year at the start of a clause (e.g. "ICML 2024, oral").
+	Confidence string
+}
+
+var (
+	commentPages   = regexp.MustCompile(`(?i)\b(\d+)\s*(?:pages|pp\.?|pgs\.?)`)
+	commentFigures = regexp.MustCompile(`(?i)\b(\d+)\s*(?:figures|figs?\b\.?)`)
+	commentTables  = regexp.MustCompile(`(?i)\b(\d+)\s*tables?\b`)
+
+	// commentStatuses are tried in order. Words that are common in prose,
+	// like "appearing" or "presented in", are left out.
+	commentStatuses = []struct {
+		status string
+		re     *regexp.Regexp
+	}{
+		{CommentStatusRejected, regexp.MustCompile(`(?i)\b(?:rejected|declined)\b(?:\s+(?:at|from|by|for|to)\s+(?:the\s+)?(?P<venue>[^,;()]+))?`)},
+		{CommentStatusAccepted, regexp.MustCompile(`(?i)\b(?:accepted|to appear|camera[- ]ready)\b(?:\s+(?:at|in|by|for|to|as)\s+(?:the\s+)?(?:(?:main|full|long|short|oral|poster|spotlight)\s+)*(?:(?:paper|publication|presentation)\s+(?:at|in)\s+(?:the\s+)?)?(?P<venue>[^,;()]+))?`)},
+		{CommentStatusPublished, regexp.MustCompile(`(?i)\b(?:published\s+(?:at|in|by)|presented\s+at)\s+(?:the\s+)?(?P<venue>[^,;()]+)`)},
+		{CommentStatusUnderReview, regexp.MustCompile(`(?i)\bunder review\b(?:\s+(?:at|for|by)\s+(?:the\s+)?(?P<venue>[^,;()]+))?`)},
+		{CommentStatusSubmitted, regexp.MustCompile(`(?i)\bsubmitted\s+(?:to|at|for)\s+(?:the\s+)?(?P<venue>[^,;()]+)`)},
+	}
+	// commentVenueEnd matches the end of a venue followed by another sentence,
+	// e.g. "Nature Physics. 20 pages".
+	commentVenueEnd = regexp.MustCompile(`(?:\.\s+\d.*|\.)$`)
+	// commentNegation matches a negation right before a status, e.g. "not" in
+	// "not accepted" or "hasn't been" in "hasn't been published".
+	commentNegation = regexp.MustCompile(`(?i)(?:\bnot|\bnever|n't)\s+(?:(?:yet|been|be)\s+)*$`)
+	// commentVenueYear matches a venue acronym followed by a year at the start
+	// of a clause, e.g. "NeurIPS 2023" or "CVPR'24". Acronyms within a
+	// sentence, like "the COVID 2020 wave", are rarely venues.
+	commentVenueYear = regexp.MustCompile(`(?:^|[,;(]\s*)(?P<venue>[A-Z][A-Za-z]*[A-Z][A-Za-z-]*\s*(?:'(?P<short>\d{2})|(?:19|20)\d{2}))\b`)
+)
+
+// ParseComment parses an author comment like "12 pages, 5 figures, accepted
+// at ICML 2024". It returns false if nothing could be extracted.
+func ParseComment(s string) (Comment, bool) {
+	s = collapseSpace(s)
+	c := Comment{
+		Pages:   firstInt(commentPages, s),
+		Figures: firstInt(commentFigures, s),
+		Tables:  firstInt(commentTables, s),
+	}
+
+	negated := false
+	for _, st := range commentStatuses {
+		m, ok := findStatus(st.re, s)
+		if !ok {
+			negated = negated || m != nil
+			continue
+		}
+		c.Status = st.status
+		c.Confidence = ConfidenceHigh
+		if i := st.re.SubexpIndex("venue"); i >= 0 {
+			c.Venue = commentVenueEnd.ReplaceAllString(strings.TrimSpace(m[i]), "")
+		}
+		c.VenueYear = lastYear(c.Venue)
+		break
+	}
+
+	// a venue isn't guessed if the comment negates a status, e.g. "not
+	// accepted at ICML 2024"
+	if c.Status == "" && !negated {
+		if m := commentVenueYear.FindStringSubmatch(s); m != nil {
+			c.Status = CommentStatusAccepted
+			c.Confidence = ConfidenceLow
+			c.Venue = strings.TrimSpace(m[commentVenueYear.SubexpIndex("venue")])
+			c.VenueYear = lastYear(c.Venue)
+			if short := m[commentVenueYear.SubexpIndex("short")]; short != "" {
+				year, _ := strconv.Atoi(short)
+				c.VenueYear = 2000 + year
+			}
+		}
+	}
+
+	if c == (Comment{}) {
+		return Comment{}, false
+	}
+	if c.Confidence == "" {
+		c.Confidence = ConfidenceHigh
+	}
+	return c, true
+}
+
+// PeerReviewed reports whether the comment explicitly states that the paper
+// was accepted or published. Guessed statuses don't count.
+func (c Comment) PeerReviewed() bool {
+	if c.Confidence != ConfidenceHigh {
+		return false
+	}
+	return c.Status == CommentStatusAccepted || c.Status == CommentStatusPublished
+}
+
+// findStatus returns the submatches of the first match of a status that is
+// not negated. If all matches are negated, it returns the first one and
+// false.
+func findStatus(re *regexp.Regexp, s string) ([]string, bool) {
+	var negated []string
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = s[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		if !commentNegation.MatchString(s[:loc[0]]) {
+			return m, true
+		}
+		if negated == nil {
+			negated = m
+		}
+	}
+	return negated, false
+}
+
+func firstInt(re *regexp.Regexp, s string) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+func lastYear(s string) int {
+	years := yearRegex.FindAllString(s, -1)
+	if len(years) == 0 {
+		return 0
+	}
+	year, _ := strconv.Atoi(years[len(years)-1])
+	return year
+}
+
+// journalRefData returns the payload value of a parsed journal reference.
+func journalRefData(s string) interface{} {
+	ref, ok := ParseJournalRef(s)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"venue":      ref.Venue,
+		"volume":     nullableString(ref.Volume),
+		"issue":      nullableString(ref.Issue),
+		"pages":      nullableString(ref.Pages),
+		"year":       nullableInt(ref.Year),
+		"confidence": ref.Confidence,
+	}
+}
+
+// commentData returns the payload value of a parsed comment.
+func commentData(s string) interface{} {
+	c, ok := ParseComment(s)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"pages":      nullableInt(c.Pages),
+		"figures":    nullableInt(c.Figures),
+		"tables":     nullableInt(c.Tables),
+		"status":     nullableString(c.Status),
+		"venue":      nullableString(c.Venue),
+		"venue_year": nullableInt(c.VenueYear),
+		"confidence": c.Confidence,
+	}
+}
+
+// nullableInt returns nil for 0, so that it is encoded as null in the
+// payload.
+func nullableInt(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// peerReviewed reports whether an entry was published in a journal or its
+// comment says it was accepted or published somewhere.
+func peerReviewed(e *ArxivEntry) bool {
+	if _, ok := ParseJournalRef(e.JournalRef); ok {
+		return true
+	}
+	c, _ := ParseComment(e.Comment)
+	return c.PeerReviewed()
+}
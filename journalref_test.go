@@ -0,0 +1,201 @@
+package arxiv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func TestParseJournalRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want arxiv.JournalRef
+	}{
+		{"Phys. Rev. D 99, 123456 (2019)", arxiv.JournalRef{Venue: "Phys. Rev. D", Volume: "99", Pages: "123456", Year: 2019, Confidence: arxiv.ConfidenceHigh}},
+		{"Nature 521, 436-444 (2015)", arxiv.JournalRef{Venue: "Nature", Volume: "521", Pages: "436-444", Year: 2015, Confidence: arxiv.ConfidenceHigh}},
+		{"J. Mach. Learn. Res. 15(1):1929--1958, 2014", arxiv.JournalRef{Venue: "J. Mach. Learn. Res.", Volume: "15", Issue: "1", Pages: "1929--1958", Year: 2014, Confidence: arxiv.ConfidenceHigh}},
+		{"Astrophys.J. 600 (2004) 580-590", arxiv.JournalRef{Venue: "Astrophys.J.", Volume: "600", Pages: "580-590", Year: 2004, Confidence: arxiv.ConfidenceHigh}},
+		{"JHEP 0906:081,2009", arxiv.JournalRef{Venue: "JHEP", Volume: "0906", Pages: "081", Year: 2009, Confidence: arxiv.ConfidenceHigh}},
+		{"Phys.\n  Lett. B 716 (2012) 1-29", arxiv.JournalRef{Venue: "Phys. Lett. B", Volume: "716", Pages: "1-29", Year: 2012, Confidence: arxiv.ConfidenceHigh}},
+		{"Proceedings of the 41st International Conference on Machine Learning, 2024", arxiv.JournalRef{Venue: "Proceedings of the", Year: 2024, Confidence: arxiv.ConfidenceLow}},
+		{"Annals of Statistics, to appear", arxiv.JournalRef{Venue: "Annals of Statistics, to appear", Confidence: arxiv.ConfidenceLow}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			is := is.New(t)
+			got, ok := arxiv.ParseJournalRef(tt.in)
+			is.True(ok)
+			is.Equal(got, tt.want)
+		})
+	}
+
+	for _, in := range []string{"", "  ", "2019"} {
+		_, ok := arxiv.ParseJournalRef(in)
+		if ok {
+			t.Errorf("expected %q not to be parsed", in)
+		}
+	}
+}
+
+func TestParseComment(t *testing.T) {
+	tests := []struct {
+		in   string
+		want arxiv.Comment
+		ok   bool
+	}{
+		{"12 pages, 5 figures, accepted at ICML 2024", arxiv.Comment{Pages: 12, Figures: 5, Status: arxiv.CommentStatusAccepted, Venue: "ICML 2024", VenueYear: 2024, Confidence: arxiv.ConfidenceHigh}, true},
+		{"Accepted to ACL 2023 (main conference); 8 pages, 3 tables", arxiv.Comment{Pages: 8, Tables: 3, Status: arxiv.CommentStatusAccepted, Venue: "ACL 2023", VenueYear: 2023, Confidence: arxiv.ConfidenceHigh}, true},
+		{"To appear in Physical Review Letters", arxiv.Comment{Status: arxiv.CommentStatusAccepted, Venue: "Physical Review Letters", Confidence: arxiv.ConfidenceHigh}, true},
+		{"Published in Nature Physics. 20 pp., 4 figs.", arxiv.Comment{Pages: 20, Figures: 4, Status: arxiv.CommentStatusPublished, Venue: "Nature Physics", Confidence: arxiv.ConfidenceHigh}, true},
+		{"Submitted to NeurIPS 2024", arxiv.Comment{Status: arxiv.CommentStatusSubmitted, Venue: "NeurIPS 2024", VenueYear: 2024, Confidence: arxiv.ConfidenceHigh}, true},
+		{"Submitted to NeurIPS 2024; accepted at ICLR 2025", arxiv.Comment{Status: arxiv.CommentStatusAccepted, Venue: "ICLR 2025", VenueYear: 2025, Confidence: arxiv.ConfidenceHigh}, true},
+		{"under review", arxiv.Comment{Status: arxiv.CommentStatusUnderReview, Confidence: arxiv.ConfidenceHigh}, true},
+		{"CVPR 2024 (highlight), code available", arxiv.Comment{Status: arxiv.CommentStatusAccepted, Venue: "CVPR 2024", VenueYear: 2024, Confidence: arxiv.ConfidenceLow}, true},
+		{"NeurIPS'23 workshop", arxiv.Comment{Status: arxiv.CommentStatusAccepted, Venue: "NeurIPS'23", VenueYear: 2023, Confidence: arxiv.ConfidenceLow}, true},
+		{"Rejected from ICLR 2024", arxiv.Comment{Status: arxiv.CommentStatusRejected, Venue: "ICLR 2024", VenueYear: 2024, Confidence: arxiv.ConfidenceHigh}, true},
+		{"not accepted", arxiv.Comment{}, false},
+		{"This paper has not been accepted at ICML 2024; 9 pages", arxiv.Comment{Pages: 9, Confidence: arxiv.ConfidenceHigh}, true},
+		{"Analysis of the COVID 2020 wave", arxiv.Comment{}, false},
+		{"Results presented in the previous version were wrong", arxiv.Comment{}, false},
+		{"The figures appearing in Sec. 3 were corrected", arxiv.Comment{}, false},
+		{"Presented at the ICML 2024 workshop", arxiv.Comment{Status: arxiv.CommentStatusPublished, Venue: "ICML 2024 workshop", VenueYear: 2024, Confidence: arxiv.ConfidenceHigh}, true},
+		{"Code for the NeurIPS 2023 competition", arxiv.Comment{}, false},
+		{"30 pages", arxiv.Comment{Pages: 30, Confidence: arxiv.ConfidenceHigh}, true},
+		{"v2: fixed typos", arxiv.Comment{}, false},
+		{"", arxiv.Comment{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			is := is.New(t)
+			got, ok := arxiv.ParseComment(tt.in)
+			is.Equal(ok, tt.ok)
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestComment_PeerReviewed(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"accepted at ICML 2024", true},
+		{"Published in Nature Physics", true},
+		{"Rejected from ICLR 2024", false},
+		{"not accepted", false},
+		{"Analysis of the COVID 2020 wave", false},
+		{"Code for the NeurIPS 2023 competition", false},
+		{"Results presented in the previous version were wrong", false},
+		{"The figures appearing in Sec. 3 were corrected", false},
+		// guessed statuses don't count
+		{"CVPR 2024 (highlight), code available", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			is := is.New(t)
+			c, _ := arxiv.ParseComment(tt.in)
+			is.Equal(c.PeerReviewed(), tt.want)
+		})
+	}
+}
+
+func TestSource_JournalRefAndComment(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := arxivtest.NewServer([]arxivtest.Paper{
+		{
+			ID:         "2401.00001",
+			Published:  published,
+			Categories: []string{"hep-th"},
+			JournalRef: "Phys. Rev. D 99, 123456 (2019)",
+			Comment:    "12 pages,\n 5 figures",
+		},
+		{
+			ID:         "2401.00002",
+			Published:  published,
+			Categories: []string{"hep-th"},
+		},
+		{
+			ID:         "2401.00003",
+			Published:  published,
+			Categories: []string{"hep-th"},
+			Comment:    "accepted at ICML 2024",
+		},
+	})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":                       "cat:hep-th",
+		"sort_by":                            "relevance",
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	out := decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["journal_ref"], "Phys. Rev. D 99, 123456 (2019)")
+	is.Equal(out["journal"], map[string]interface{}{
+		"venue":      "Phys. Rev. D",
+		"volume":     "99",
+		"issue":      nil,
+		"pages":      "123456",
+		"year":       2019,
+		"confidence": "high",
+	})
+	is.Equal(out["comment"], "12 pages, 5 figures")
+	is.Equal(out["comment_details"], map[string]interface{}{
+		"pages":      12,
+		"figures":    5,
+		"tables":     nil,
+		"status":     nil,
+		"venue":      nil,
+		"venue_year": nil,
+		"confidence": "high",
+	})
+	is.Equal(out["peer_reviewed"], true)
+
+	rec, err = src.Read(ctx)
+	is.NoErr(err)
+	out = decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["journal"], nil)
+	is.Equal(out["comment_details"], nil)
+	is.Equal(out["peer_reviewed"], false)
+
+	rec, err = src.Read(ctx)
+	is.NoErr(err)
+	out = decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["peer_reviewed"], true)
+}
+
+func TestSource_PeerReviewedFilter(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := arxivtest.NewServer([]arxivtest.Paper{
+		{ID: "2401.00001", Published: published, Categories: []string{"cs.LG"}, Comment: "submitted to ICML 2024"},
+		{ID: "2401.00002", Published: published, Categories: []string{"cs.LG"}, Comment: "camera-ready version for NeurIPS 2024"},
+		{ID: "2401.00003", Published: published, Categories: []string{"cs.LG"}, JournalRef: "Nature 521, 436-444 (2015)"},
+	})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":         "cat:cs.LG",
+		"sort_by":              "relevance",
+		"filter.peer_reviewed": "true",
+	})
+
+	var keys []string
+	for range 2 {
+		rec, err := src.Read(ctx)
+		is.NoErr(err)
+		keys = append(keys, string(rec.Key.Bytes()))
+	}
+	is.Equal(keys, []string{"2401.00002", "2401.00003"})
+}
@@ -0,0 +1,106 @@
+package arxiv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSource_ReadN_PartialBatch(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, threeEntryResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, nil)
+
+	// the page only has 3 records, so a partial batch is returned
+	recs, err := src.ReadN(ctx, 2)
+	is.NoErr(err)
+	is.Equal(len(recs), 2)
+	is.Equal(string(recs[0].Key.Bytes()), "2401.11111")
+	is.Equal(string(recs[1].Key.Bytes()), "2401.22222")
+
+	recs, err = src.ReadN(ctx, 5)
+	is.NoErr(err)
+	is.Equal(len(recs), 1)
+	is.Equal(string(recs[0].Key.Bytes()), "2401.33333")
+
+	for _, p := range []string{"0", "1", "2"} {
+		is.NoErr(src.Ack(ctx, []byte(p)))
+	}
+}
+
+func TestSource_ReadN_ContextCancelled(t *testing.T) {
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	src := openTestSource(context.Background(), t, server.URL, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	recs, err := src.ReadN(ctx, 10)
+	is.Equal(err, context.Canceled)
+	is.Equal(len(recs), 0)
+}
+
+func TestSource_ReadN_WithBatching(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	server := createPagingServer(t, &requests)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"polling_period":  "10ms",
+		"sdk.batch.size":  "3",
+		"sdk.batch.delay": "1s",
+	})
+
+	// every page contains a single record, the batch is collected from
+	// multiple pages
+	recs, err := src.ReadN(ctx, 3)
+	is.NoErr(err)
+	is.Equal(len(recs), 3)
+	is.Equal(string(recs[2].Key.Bytes()), "2401.00003")
+}
+
+const threeEntryResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.11111v1</id>
+    <title>First Paper</title>
+    <summary>First Summary</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-02T00:00:00Z</updated>
+  </entry>
+  <entry>
+    <id>http://arxiv.org/abs/2401.22222v1</id>
+    <title>Second Paper</title>
+    <summary>Second Summary</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-02T00:00:00Z</updated>
+  </entry>
+  <entry>
+    <id>http://arxiv.org/abs/2401.33333v1</id>
+    <title>Third Paper</title>
+    <summary>Third Summary</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-02T00:00:00Z</updated>
+  </entry>
+</feed>`
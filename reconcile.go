@@ -0,0 +1,223 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"golang.org/x/time/rate"
+)
+
+// ReconcileConfig configures the periodic reconciliation of emitted papers
+// with their current state on arXiv.
+type ReconcileConfig struct {
+	// Interval is how often all papers emitted so far are re-fetched and
+	// compared with the state they were emitted in. Changed papers are
+	// emitted as updates, papers that were withdrawn or no longer match
+	// search_query as deletes. 0 disables reconciliation.
+	Interval time.Duration `json:"interval" default:"0s"`
+	// BatchSize is the number of papers re-fetched with a single id_list
+	// request.
+	BatchSize int `json:"batch_size" default:"100"`
+	// RequestInterval is the minimum time between two reconciliation
+	// requests.
+	RequestInterval time.Duration `json:"request_interval" default:"3s"`
+}
+
+func (c ReconcileConfig) Validate(stateFile string) error {
+	if c.Interval < 0 {
+		return fmt.Errorf("reconcile.interval must not be negative")
+	}
+	if c.Interval == 0 {
+		return nil
+	}
+	if stateFile == "" {
+		return fmt.Errorf("state_file is required when reconcile.interval is set")
+	}
+	if c.BatchSize <= 0 || c.BatchSize > 2000 {
+		return fmt.Errorf("reconcile.batch_size must be between 1 and 2000")
+	}
+	if c.RequestInterval < 0 {
+		return fmt.Errorf("reconcile.request_interval must not be negative")
+	}
+	return nil
+}
+
+// reconcilePositionSeparator separates the offset of the regular results
+// from the sequence number of a reconciliation record in its position.
+const reconcilePositionSeparator = "#r"
+
+// reconciler re-fetches emitted papers and emits the changes.
+type reconciler struct {
+	interval  time.Duration
+	batchSize int
+	limiter   *rate.Limiter
+	// seq numbers the emitted records, so their positions are unique.
+	seq int
+}
+
+func newReconciler(c ReconcileConfig) *reconciler {
+	limit := rate.Inf
+	if c.RequestInterval > 0 {
+		limit = rate.Every(c.RequestInterval)
+	}
+	return &reconciler{
+		interval:  c.Interval,
+		batchSize: c.BatchSize,
+		limiter:   rate.NewLimiter(limit, 1),
+	}
+}
+
+// position returns the position of the next reconciliation record. It
+// starts with the position of the next regular result, so resuming from it
+// continues with that result.
+func (r *reconciler) position(next opencdc.Position) opencdc.Position {
+	r.seq++
+	return opencdc.Position(string(next) + reconcilePositionSeparator + strconv.Itoa(r.seq))
+}
+
+// regularPosition strips the reconciliation suffix from a position.
+func regularPosition(pos opencdc.Position) opencdc.Position {
+	s, _, _ := strings.Cut(string(pos), reconcilePositionSeparator)
+	return opencdc.Position(s)
+}
+
+// parseOffset returns the offset of the regular results stored in a position.
+func parseOffset(pos opencdc.Position) (int, bool) {
+	offset, err := strconv.Atoi(string(regularPosition(pos)))
+	return offset, err == nil
+}
+
+// reconcileDue reports whether the emitted papers should be reconciled.
+func (s *Source) reconcileDue() bool {
+	return s.reconciler != nil && time.Since(s.state.lastReconciled()) >= s.reconciler.interval
+}
+
+// reconcile re-fetches all emitted papers in batches and sends the records
+// of changed papers to pages. A failed run is logged and retried once the
+// interval elapsed again, it only returns context errors.
+func (s *Source) reconcile(ctx context.Context, pages chan<- page) error {
+	ids := s.state.ids()
+	sdk.Logger(ctx).Info().Int("papers", len(ids)).Msg("reconciling emitted arXiv papers")
+
+	started := time.Now()
+	emitted := 0
+	for len(ids) > 0 {
+		batch := ids[:min(s.reconciler.batchSize, len(ids))]
+		ids = ids[len(batch):]
+
+		records, err := s.reconcileBatch(ctx, batch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			sdk.Logger(ctx).Warn().Err(err).Msg("failed to reconcile arXiv papers")
+			break
+		}
+		if len(records) == 0 {
+			continue
+		}
+		select {
+		case pages <- page{records: records}:
+			emitted += len(records)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	sdk.Logger(ctx).Info().
+		Int("changed", emitted).
+		Dur("duration", time.Since(started)).
+		Msg("reconciled emitted arXiv papers")
+	if err := s.state.reconciled(started); err != nil {
+		sdk.Logger(ctx).Warn().Err(err).Msg("failed to persist reconciliation time")
+	}
+	return nil
+}
+
+// reconcileBatch re-fetches a batch of papers and returns the records of the
+// papers that changed since they were emitted.
+func (s *Source) reconcileBatch(ctx context.Context, ids []string) ([]opencdc.Record, error) {
+	current, err := s.fetchIDs(ctx, ids, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		// arXiv doesn't remove papers, an empty response is a hiccup
+		return nil, fmt.Errorf("no papers returned for %d IDs", len(ids))
+	}
+	matching, err := s.fetchIDs(ctx, ids, s.config.SearchQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []opencdc.Record
+	for _, id := range ids {
+		entry, ok := current[id]
+		if !ok {
+			sdk.Logger(ctx).Warn().Str("arxiv_id", id).Msg("paper not returned by arXiv, skipping reconciliation")
+			continue
+		}
+		_, matches := matching[id]
+		entry.unmatched = !matches
+
+		if s.versions != nil && !entry.unmatched {
+			if err := s.versions.annotate(ctx, entry); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				sdk.Logger(ctx).Warn().Err(err).Str("entry", entry.ID).Msg("failed to fetch version history")
+			}
+		}
+
+		position := s.reconciler.position(s.position(s.offset))
+		rec, st, err := s.entryToRecord(*entry, position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert entry to record: %w", err)
+		}
+		changed, err := s.detectChange(&rec, st)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			continue
+		}
+		rec.Metadata["arxiv.reconciled"] = "true"
+		s.state.stage(position, st)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// fetchIDs fetches the latest versions of the papers with the given
+// versionless IDs, keyed by their versionless ID. If searchQuery is set, only
+// the papers matching it are returned.
+func (s *Source) fetchIDs(ctx context.Context, ids []string, searchQuery string) (map[string]*ArxivEntry, error) {
+	if err := s.reconciler.limiter.Wait(ctx); err != nil {
+		return nil, err //nolint:wrapcheck // context errors are returned as is
+	}
+	apiURL, err := s.config.BuildArxivIDListURL(ids, searchQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build arXiv URL: %w", err)
+	}
+	// reconciliation looks for changes, cached responses must be revalidated
+	feed, err := s.fetchFeed(withRevalidation(ctx), apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*ArxivEntry, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		id, err := ParseIdentifier(entry.ID)
+		if err != nil {
+			sdk.Logger(ctx).Warn().Err(err).Str("entry", entry.ID).Msg("skipping arXiv entry with invalid identifier")
+			continue
+		}
+		entries[id.Base()] = entry
+	}
+	return entries, nil
+}
@@ -0,0 +1,157 @@
+package arxiv_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func reconcileCorpus() []arxivtest.Paper {
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	paper := func(id string) arxivtest.Paper {
+		return arxivtest.Paper{
+			ID:         id,
+			Title:      "Paper " + id,
+			Summary:    "An abstract.",
+			Authors:    []string{"Jane Doe"},
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		}
+	}
+	return []arxivtest.Paper{
+		paper("2401.00001"),
+		paper("2401.00002"),
+		paper("2401.00003"),
+		paper("2401.00004"),
+	}
+}
+
+// readN reads n records, retrying while the source backs off.
+func readN(ctx context.Context, t *testing.T, src sdk.Source, n int) []opencdc.Record {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var recs []opencdc.Record
+	for len(recs) < n {
+		rec, err := src.Read(ctx)
+		if err == sdk.ErrBackoffRetry {
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to read record %d of %d: %v", len(recs)+1, n, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestSource_Reconcile(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	corpus := reconcileCorpus()
+	server := arxivtest.NewServer(corpus)
+	t.Cleanup(server.Close)
+
+	stateFile := filepath.Join(t.TempDir(), "state", "papers.json")
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":               "cat:cs.LG",
+		"sort_by":                    "relevance",
+		"reconcile.interval":         "200ms",
+		"state_file":                 stateFile,
+		"reconcile.batch_size":       "3",
+		"reconcile.request_interval": "0s",
+	})
+
+	recs := readAll(ctx, t, src)
+	is.Equal(len(recs), 4)
+	for _, rec := range recs {
+		is.NoErr(src.Ack(ctx, rec.Position))
+	}
+
+	// a new version was submitted
+	updated := corpus[0]
+	updated.Version = 2
+	updated.Title = "Paper 2401.00001, revised"
+	updated.Updated = updated.Published.Add(24 * time.Hour)
+	// recategorized, so it no longer matches the query
+	moved := corpus[1]
+	moved.Categories = []string{"cs.CV"}
+	// withdrawn
+	withdrawn := corpus[3]
+	withdrawn.Version = 2
+	withdrawn.Comment = "This paper has been withdrawn"
+	server.Add(updated, moved, withdrawn)
+
+	recs = readN(ctx, t, src, 3)
+
+	is.Equal(recs[0].Operation, opencdc.OperationUpdate)
+	is.Equal(recs[0].Key, opencdc.RawData("2401.00001"))
+	is.Equal(recs[0].Payload.After.(opencdc.StructuredData)["title"], "Paper 2401.00001, revised")
+	is.Equal(recs[0].Metadata["arxiv.reconciled"], "true")
+
+	is.Equal(recs[1].Operation, opencdc.OperationDelete)
+	is.Equal(recs[1].Key, opencdc.RawData("2401.00002"))
+	is.Equal(recs[1].Metadata["arxiv.reconciled"], "true")
+
+	is.Equal(recs[2].Operation, opencdc.OperationDelete)
+	is.Equal(recs[2].Key, opencdc.RawData("2401.00004"))
+	is.Equal(recs[2].Metadata["arxiv.withdrawn"], "true")
+
+	for _, rec := range recs {
+		is.NoErr(src.Ack(ctx, rec.Position))
+	}
+	is.NoErr(src.Teardown(ctx))
+
+	// the persisted state skips the unchanged papers when reading again, the
+	// delete of the withdrawn paper isn't repeated either
+	src = openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query": "cat:cs.LG",
+		"sort_by":      "relevance",
+		"state_file":   stateFile,
+	})
+	recs = readAll(ctx, t, src)
+	is.Equal(len(recs), 0)
+}
+
+func TestSource_ReconcileResumePosition(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(reconcileCorpus())
+	t.Cleanup(server.Close)
+
+	src := arxiv.NewSource()
+	is.NoErr(configureTestSource(ctx, src, server.URL(), map[string]string{
+		"search_query": "cat:cs.LG",
+		"sort_by":      "relevance",
+	}))
+	// positions of reconciliation records resume at their offset
+	is.NoErr(src.Open(ctx, opencdc.Position("3#r2")))
+	t.Cleanup(func() { _ = src.Teardown(context.Background()) })
+
+	recs := readAll(ctx, t, src)
+	is.Equal(len(recs), 1)
+	is.Equal(recs[0].Position, opencdc.Position("3"))
+}
+
+func TestSourceConfig_ReconcileRequiresStateFile(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	err := openTestSourceErr(ctx, t, "http://localhost", map[string]string{
+		"reconcile.interval": "24h",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "state_file is required"))
+}
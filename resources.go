@@ -0,0 +1,213 @@
+package arxiv
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// resourceLinks are the code, dataset and project links mentioned in the
+// abstract or comment of an entry.
+type resourceLinks struct {
+	code     []string
+	datasets []string
+	projects []string
+}
+
+var (
+	// urlRegex matches URLs with a scheme or starting with www., as well as
+	// bare links to well-known code and data hosts.
+	urlRegex = regexp.MustCompile(`(?i)(?:\bhttps?://|\bwww\.|\b(?:github\.com|gitlab\.com|bitbucket\.org|codeberg\.org|huggingface\.co|zenodo\.org|kaggle\.com|figshare\.com|osf\.io)/)[^\s<>"'\x60]+`)
+	// wrappedURLRegex matches URLs broken over two lines after a character
+	// that rarely ends a URL in running text, and the word on the next line.
+	wrappedURLRegex = regexp.MustCompile(`(?:https?://|www\.)\S*([/\-_=?&#])\s*\n\s*(\S+)`)
+	// urlContinuationRegex matches words that look like the rest of a URL
+	// rather than prose.
+	urlContinuationRegex = regexp.MustCompile(`[/.\-_=?&#%~0-9]`)
+	// latexURLRegex matches \url{...} and \href{...}{...}.
+	latexURLRegex = regexp.MustCompile(`\\(?:url|href)\{([^}]*)\}`)
+)
+
+const (
+	resourceCode    = "code"
+	resourceDataset = "dataset"
+	resourceProject = "project"
+)
+
+// extractResourceLinks finds links in the given texts, normalizes and
+// classifies them by host. Links to arXiv and DOI resolvers are ignored.
+func extractResourceLinks(texts ...string) resourceLinks {
+	links := resourceLinks{
+		code:     []string{},
+		datasets: []string{},
+		projects: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		text = latexURLRegex.ReplaceAllString(text, " $1 ")
+		text = joinWrappedURLs(text)
+		for _, raw := range urlRegex.FindAllString(text, -1) {
+			u, ok := normalizeURL(raw)
+			if !ok {
+				continue
+			}
+			// links are deduplicated regardless of their escaping
+			unescaped := *u
+			unescaped.RawPath = ""
+			if seen[unescaped.String()] {
+				continue
+			}
+			seen[unescaped.String()] = true
+
+			switch classifyURL(u) {
+			case resourceCode:
+				links.code = append(links.code, u.String())
+			case resourceDataset:
+				links.datasets = append(links.datasets, u.String())
+			case resourceProject:
+				links.projects = append(links.projects, u.String())
+			}
+		}
+	}
+	return links
+}
+
+// joinWrappedURLs joins URLs broken over two lines. A URL ending in a slash
+// is only joined with a word that looks like the rest of it, so "code at
+// https://github.com/foo/bar/\nwhich" keeps the word out of the URL.
+func joinWrappedURLs(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range wrappedURLRegex.FindAllStringSubmatchIndex(text, -1) {
+		end, next := text[m[2]:m[3]], text[m[4]:m[5]]
+		if end == "/" && !urlContinuationRegex.MatchString(next) {
+			continue
+		}
+		if loc := urlRegex.FindStringIndex(next); loc != nil && loc[0] == 0 {
+			continue // the next line starts another URL
+		}
+		b.WriteString(text[last:m[3]])
+		last = m[4]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// normalizeURL cleans up a URL found in text: trailing punctuation and
+// unbalanced closing brackets are removed, the scheme defaults to https and
+// the host is lowercased.
+func normalizeURL(raw string) (*url.URL, bool) {
+	raw = trimURLSuffix(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || !strings.Contains(u.Host, ".") {
+		return nil, false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if knownResourceHost(u.Host) {
+		// well-known hosts are only served over https
+		u.Scheme = "https"
+	}
+	// the original escaping is kept, RawPath is ignored if it no longer
+	// matches Path
+	if codeHosts[u.Host] {
+		u.Path = strings.TrimSuffix(u.Path, ".git")
+		u.RawPath = strings.TrimSuffix(u.RawPath, ".git")
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u, true
+}
+
+func trimURLSuffix(s string) string {
+	for {
+		trimmed := strings.TrimRight(s, ".,;:!?*")
+		for _, pair := range []string{"()", "[]", "{}"} {
+			if strings.HasSuffix(trimmed, pair[1:]) &&
+				strings.Count(trimmed, pair[:1]) < strings.Count(trimmed, pair[1:]) {
+				trimmed = trimmed[:len(trimmed)-1]
+			}
+		}
+		if trimmed == s {
+			return s
+		}
+		s = trimmed
+	}
+}
+
+var codeHosts = map[string]bool{
+	"github.com":      true,
+	"gitlab.com":      true,
+	"bitbucket.org":   true,
+	"codeberg.org":    true,
+	"sourceforge.net": true,
+}
+
+var datasetHosts = map[string]bool{
+	"zenodo.org":        true,
+	"figshare.com":      true,
+	"osf.io":            true,
+	"dataverse.org":     true,
+	"data.mendeley.com": true,
+}
+
+// datasetDOIPrefixes are the prefixes of the DOIs minted by dataset hosts.
+var datasetDOIPrefixes = []string{
+	"10.5281/zenodo.",
+	"10.6084/m9.figshare.",
+}
+
+var ignoredHosts = map[string]bool{
+	"arxiv.org":        true,
+	"export.arxiv.org": true,
+	"doi.org":          true,
+	"dx.doi.org":       true,
+}
+
+func knownResourceHost(host string) bool {
+	return codeHosts[host] || datasetHosts[host] || host == "huggingface.co" || host == "kaggle.com"
+}
+
+// classifyURL returns whether a URL points to code, a dataset or a project
+// page, or an empty string if it should be ignored.
+func classifyURL(u *url.URL) string {
+	host := u.Host
+	switch {
+	case (host == "doi.org" || host == "dx.doi.org") && datasetDOI(u.Path):
+		return resourceDataset
+	case ignoredHosts[host]:
+		return ""
+	case codeHosts[host]:
+		return resourceCode
+	case datasetHosts[host]:
+		return resourceDataset
+	case host == "huggingface.co":
+		switch {
+		case strings.HasPrefix(u.Path, "/datasets/"):
+			return resourceDataset
+		case strings.HasPrefix(u.Path, "/spaces/"):
+			return resourceProject
+		default: // models
+			return resourceCode
+		}
+	case host == "kaggle.com" && strings.HasPrefix(u.Path, "/datasets/"):
+		return resourceDataset
+	default:
+		return resourceProject
+	}
+}
+
+// datasetDOI reports whether the path of a DOI URL is a DOI of a dataset
+// host. Other DOIs usually point to papers.
+func datasetDOI(path string) bool {
+	doi := strings.ToLower(strings.TrimPrefix(path, "/"))
+	for _, prefix := range datasetDOIPrefixes {
+		if strings.HasPrefix(doi, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package arxiv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func readResourceLinks(t *testing.T, summary, comment string) opencdc.StructuredData {
+	t.Helper()
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer([]arxivtest.Paper{{
+		ID:         "2401.00001",
+		Summary:    summary,
+		Comment:    comment,
+		Published:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"cs.LG"},
+	}})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{"search_query": "cat:cs.LG"})
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	return data
+}
+
+func TestResourceLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  string
+		comment  string
+		code     []string
+		datasets []string
+		projects []string
+	}{
+		{
+			name:    "nothing",
+			summary: "An abstract without links.",
+		},
+		{
+			name:    "trailing punctuation",
+			summary: "Code is available at https://github.com/Foo/Bar. Data (https://zenodo.org/records/123) too.",
+			code:    []string{"https://github.com/Foo/Bar"},
+			datasets: []string{
+				"https://zenodo.org/records/123",
+			},
+		},
+		{
+			name:    "line-wrapped URL",
+			summary: "See https://github.com/example/\n  long-repository-name for details.",
+			code:    []string{"https://github.com/example/long-repository-name"},
+		},
+		{
+			name:    "bare hosts and normalization",
+			summary: "Code: github.com/org/repo.git; models at http://www.HuggingFace.co/org/model/ and",
+			comment: "demo at huggingface.co/spaces/org/demo, dataset huggingface.co/datasets/org/data",
+			code:    []string{"https://github.com/org/repo", "https://huggingface.co/org/model"},
+			datasets: []string{
+				"https://huggingface.co/datasets/org/data",
+			},
+			projects: []string{"https://huggingface.co/spaces/org/demo"},
+		},
+		{
+			name:     "project pages and ignored hosts",
+			summary:  "Project page: https://example.github.io/project/ (see also https://arxiv.org/abs/2301.00001 and https://doi.org/10.1000/x)",
+			comment:  `12 pages, code at \url{https://gitlab.com/group/repo}`,
+			code:     []string{"https://gitlab.com/group/repo"},
+			projects: []string{"https://example.github.io/project"},
+		},
+		{
+			name:    "line break after a complete URL",
+			summary: "Code at https://github.com/foo/bar/\nwhich reproduces https://example.org/a-\nb our results, https://github.com/foo/baz/\nhttps://github.com/foo/qux.",
+			code:    []string{"https://github.com/foo/bar", "https://github.com/foo/baz", "https://github.com/foo/qux"},
+			projects: []string{
+				"https://example.org/a-b",
+			},
+		},
+		{
+			name:    "escaping is kept",
+			summary: "See https://en.wikipedia.org/wiki/Foo_(bar) and https://en.wikipedia.org/wiki/Foo_%28bar%29.",
+			projects: []string{
+				"https://en.wikipedia.org/wiki/Foo_(bar)",
+			},
+		},
+		{
+			name:    "dataset DOIs",
+			summary: "Data: https://doi.org/10.5281/zenodo.1234567 and https://dx.doi.org/10.6084/m9.figshare.7654321.v2, published as https://doi.org/10.1145/1234.5678.",
+			datasets: []string{
+				"https://doi.org/10.5281/zenodo.1234567",
+				"https://dx.doi.org/10.6084/m9.figshare.7654321.v2",
+			},
+		},
+		{
+			name:    "duplicates",
+			summary: "https://github.com/a/b and https://github.com/a/b/",
+			comment: "code: github.com/a/b",
+			code:    []string{"https://github.com/a/b"},
+		},
+		{
+			name:     "kaggle",
+			summary:  "Data from https://www.kaggle.com/datasets/user/set and https://kaggle.com/competitions/x.",
+			datasets: []string{"https://kaggle.com/datasets/user/set"},
+			projects: []string{"https://kaggle.com/competitions/x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			data := readResourceLinks(t, tt.summary, tt.comment)
+			is.Equal(data["code_urls"], orEmpty(tt.code))
+			is.Equal(data["dataset_urls"], orEmpty(tt.datasets))
+			is.Equal(data["project_urls"], orEmpty(tt.projects))
+		})
+	}
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
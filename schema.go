@@ -0,0 +1,253 @@
+package arxiv
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+// PaperSchemaVersion is the version of the typed paper schema. It is part of
+// the schema namespace and is bumped whenever a field is changed in a way that
+// is not backwards compatible.
+const PaperSchemaVersion = 1
+
+const paperSchemaName = "Paper"
+
+var paperSchemaNamespace = fmt.Sprintf("arxiv.v%d", PaperSchemaVersion)
+
+var avroNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// schemaField describes a single payload field of the typed paper schema.
+type schemaField struct {
+	name string
+	typ  avro.Schema
+	// optional fields are nullable and default to null.
+	optional bool
+	// convert turns the payload value into the value expected by the schema.
+	// If nil, the value is used as is.
+	convert func(interface{}) (interface{}, error)
+}
+
+// paperSchemaFields returns the fields of the typed paper schema, in the order
+// in which they appear in the schema.
+func paperSchemaFields() []schemaField {
+	str := func() avro.Schema { return avro.NewPrimitiveSchema(avro.String, nil) }
+	timestamp := func() avro.Schema {
+		return avro.NewPrimitiveSchema(avro.Long, avro.NewPrimitiveLogicalSchema(avro.TimestampMicros))
+	}
+	link := mustRecordSchema("Link", paperSchemaNamespace, []*avro.Field{
+		mustField("href", str()),
+		mustField("rel", str()),
+		mustField("type", nullable(str()), avro.WithDefault(nil)),
+		mustField("title", nullable(str()), avro.WithDefault(nil)),
+	})
+	urls := mustRecordSchema("URLs", paperSchemaNamespace, []*avro.Field{
+		mustField("abs", str()),
+		mustField("abs_versioned", nullable(str()), avro.WithDefault(nil)),
+		mustField("pdf", nullable(str()), avro.WithDefault(nil)),
+		mustField("pdf_versioned", nullable(str()), avro.WithDefault(nil)),
+		mustField("html", str()),
+		mustField("html_versioned", nullable(str()), avro.WithDefault(nil)),
+		mustField("listing", nullable(str()), avro.WithDefault(nil)),
+	})
+	integer := func() avro.Schema { return avro.NewPrimitiveSchema(avro.Int, nil) }
+	journal := mustRecordSchema("JournalRef", paperSchemaNamespace, []*avro.Field{
+		mustField("venue", str()),
+		mustField("volume", nullable(str()), avro.WithDefault(nil)),
+		mustField("issue", nullable(str()), avro.WithDefault(nil)),
+		mustField("pages", nullable(str()), avro.WithDefault(nil)),
+		mustField("year", nullable(integer()), avro.WithDefault(nil)),
+		mustField("confidence", str()),
+	})
+	comment := mustRecordSchema("CommentDetails", paperSchemaNamespace, []*avro.Field{
+		mustField("pages", nullable(integer()), avro.WithDefault(nil)),
+		mustField("figures", nullable(integer()), avro.WithDefault(nil)),
+		mustField("tables", nullable(integer()), avro.WithDefault(nil)),
+		mustField("status", nullable(str()), avro.WithDefault(nil)),
+		mustField("venue", nullable(str()), avro.WithDefault(nil)),
+		mustField("venue_year", nullable(integer()), avro.WithDefault(nil)),
+		mustField("confidence", str()),
+	})
+	version := mustRecordSchema("Version", paperSchemaNamespace, []*avro.Field{
+		mustField("version", integer()),
+		mustField("submitted", timestamp()),
+		mustField("size", nullable(avro.NewPrimitiveSchema(avro.Long, nil)), avro.WithDefault(nil)),
+	})
+
+	return []schemaField{
+		{name: "arxiv_id", typ: str()},
+		{name: "arxiv_base_id", typ: str()},
+		{name: "title", typ: str()},
+		{name: "abstract", typ: str()},
+		{name: "authors", typ: avro.NewArraySchema(str())},
+		{name: "published", typ: timestamp(), convert: parseTimestamp},
+		{name: "updated", typ: timestamp(), convert: parseTimestamp},
+		{name: "categories", typ: avro.NewArraySchema(str())},
+		{name: "category_names", typ: avro.NewArraySchema(str())},
+		{name: "archive", typ: str(), optional: true},
+		{name: "group", typ: str(), optional: true},
+		{name: "links", typ: avro.NewArraySchema(link)},
+		{name: "entry_url", typ: str()},
+		{name: "pdf_url", typ: str(), optional: true},
+		{name: "urls", typ: urls},
+		{name: "arxiv_doi", typ: str()},
+		{name: "doi", typ: str(), optional: true},
+		{name: "journal_ref", typ: str(), optional: true},
+		{name: "journal", typ: journal, optional: true, convert: unionValue(journal)},
+		{name: "comment", typ: str(), optional: true},
+		{name: "comment_details", typ: comment, optional: true, convert: unionValue(comment)},
+		{name: "peer_reviewed", typ: avro.NewPrimitiveSchema(avro.Boolean, nil)},
+		{name: "code_urls", typ: avro.NewArraySchema(str())},
+		{name: "dataset_urls", typ: avro.NewArraySchema(str())},
+		{name: "project_urls", typ: avro.NewArraySchema(str())},
+		{name: "versions", typ: avro.NewArraySchema(version), optional: true, convert: convertVersions},
+		{name: "license", typ: str(), optional: true},
+		{name: "withdrawn", typ: avro.NewPrimitiveSchema(avro.Boolean, nil)},
+		{name: "citation_bibtex", typ: str(), optional: true},
+		{name: "citation_ris", typ: str(), optional: true},
+		{name: "citation_csl_json", typ: str(), optional: true},
+	}
+}
+
+// paperSchema is the typed schema of the record payload, after the field
+// mapping has been applied.
+type paperSchema struct {
+	fields []schemaField
+}
+
+func newPaperSchema(mapper *fieldMapper) (*paperSchema, error) {
+	fields := mapper.applySchema(paperSchemaFields())
+	for _, f := range fields {
+		if !avroNameRegex.MatchString(f.name) {
+			return nil, fmt.Errorf("payload field %q is not a valid Avro field name, rename it or disable typed_schema", f.name)
+		}
+	}
+	return &paperSchema{fields: fields}, nil
+}
+
+// Bytes returns the JSON encoded Avro schema.
+func (s *paperSchema) Bytes() ([]byte, error) {
+	fields := make([]*avro.Field, len(s.fields))
+	for i, f := range s.fields {
+		if f.optional {
+			fields[i] = mustField(f.name, nullable(f.typ), avro.WithDefault(nil))
+			continue
+		}
+		fields[i] = mustField(f.name, f.typ)
+	}
+
+	rs, err := avro.NewRecordSchema(paperSchemaName, paperSchemaNamespace, fields,
+		avro.WithDoc(fmt.Sprintf("arXiv paper, schema version %d", PaperSchemaVersion)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create paper schema: %w", err)
+	}
+	b, err := rs.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal paper schema: %w", err)
+	}
+	return b, nil
+}
+
+// typed converts the payload values in data in place into the types expected
+// by the schema.
+func (s *paperSchema) typed(data map[string]interface{}) error {
+	for _, f := range s.fields {
+		v, ok := data[f.name]
+		if !ok {
+			if f.optional {
+				data[f.name] = nil
+			}
+			continue
+		}
+		if f.convert == nil || v == nil {
+			continue
+		}
+		converted, err := f.convert(v)
+		if err != nil {
+			return fmt.Errorf("failed to convert field %q: %w", f.name, err)
+		}
+		data[f.name] = converted
+	}
+	return nil
+}
+
+func parseTimestamp(v interface{}) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// convertVersions converts the submission times of a version history. The
+// history is either built from an entry or decoded from the state.
+func convertVersions(v interface{}) (interface{}, error) {
+	var versions []map[string]interface{}
+	switch v := v.(type) {
+	case []map[string]interface{}:
+		versions = v
+	case []interface{}:
+		for _, version := range v {
+			m, ok := version.(map[string]interface{})
+			if !ok {
+				return v, nil
+			}
+			versions = append(versions, m)
+		}
+	default:
+		return v, nil
+	}
+	out := make([]interface{}, len(versions))
+	for i, version := range versions {
+		converted := make(map[string]interface{}, len(version))
+		for k, v := range version {
+			converted[k] = v
+		}
+		submitted, err := parseTimestamp(version["submitted"])
+		if err != nil {
+			return nil, err
+		}
+		converted["submitted"] = submitted
+		out[i] = converted
+	}
+	return out, nil
+}
+
+// unionValue returns a converter for optional record fields. Maps in unions
+// are taken to name the union branch, so the record is wrapped in a map
+// naming its type.
+func unionValue(rs *avro.RecordSchema) func(interface{}) (interface{}, error) {
+	return func(v interface{}) (interface{}, error) {
+		return map[string]interface{}{rs.FullName(): v}, nil
+	}
+}
+
+func nullable(typ avro.Schema) avro.Schema {
+	u, err := avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), typ})
+	if err != nil {
+		panic(fmt.Errorf("failed to create union schema: %w", err))
+	}
+	return u
+}
+
+func mustField(name string, typ avro.Schema, opts ...avro.SchemaOption) *avro.Field {
+	f, err := avro.NewField(name, typ, opts...)
+	if err != nil {
+		panic(fmt.Errorf("failed to create field %q: %w", name, err))
+	}
+	return f
+}
+
+func mustRecordSchema(name, namespace string, fields []*avro.Field) *avro.RecordSchema {
+	rs, err := avro.NewRecordSchema(name, namespace, fields)
+	if err != nil {
+		panic(fmt.Errorf("failed to create record schema %q: %w", name, err))
+	}
+	return rs
+}
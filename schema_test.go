@@ -0,0 +1,114 @@
+package arxiv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/matryer/is"
+)
+
+const noPDFResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.54321v2</id>
+    <title>No PDF</title>
+    <summary>Summary</summary>
+    <author><name>Author One</name></author>
+    <author><name>Author Two</name></author>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-02T12:30:00.250Z</updated>
+    <link href="http://arxiv.org/abs/2401.54321v2" rel="alternate" type="text/html"/>
+  </entry>
+</feed>`
+
+func decodeTypedPayload(ctx context.Context, t *testing.T, rec opencdc.Record) map[string]interface{} {
+	t.Helper()
+	is := is.New(t)
+
+	subject, err := rec.Metadata.GetPayloadSchemaSubject()
+	is.NoErr(err)
+	version, err := rec.Metadata.GetPayloadSchemaVersion()
+	is.NoErr(err)
+
+	sch, err := schema.Get(ctx, subject, version)
+	is.NoErr(err)
+
+	raw, ok := rec.Payload.After.(opencdc.RawData)
+	is.True(ok) // payload should be encoded with the typed schema
+
+	var out map[string]interface{}
+	is.NoErr(sch.Unmarshal(raw.Bytes(), &out))
+	return out
+}
+
+func TestSchema_TypedPayload(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, noPDFResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	out := decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["arxiv_id"], "2401.54321v2")
+	is.Equal(out["pdf_url"], nil)
+	is.Equal(out["authors"], []interface{}{"Author One", "Author Two"})
+	is.Equal(out["published"].(time.Time).UTC(), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	links, ok := out["links"].([]interface{})
+	is.True(ok)
+	is.Equal(len(links), 1)
+	is.Equal(links[0].(map[string]interface{})["type"], "text/html")
+	is.Equal(links[0].(map[string]interface{})["title"], nil)
+}
+
+func TestSchema_TypedPayloadWithFieldMapping(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, mockArxivResponse)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, map[string]string{
+		"sdk.schema.extract.payload.enabled": "true",
+		"field_mapping.rename.abstract":      "summary",
+		"field_mapping.rename.arxiv.title":   "arxiv_title",
+		"field_mapping.drop":                 "links",
+		"field_mapping.to_payload":           "arxiv.title",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	out := decodeTypedPayload(ctx, t, rec)
+	is.Equal(out["summary"], "Sample Summary")
+	is.Equal(out["arxiv_title"], "Sample Title")
+	is.Equal(out["pdf_url"], "http://arxiv.org/pdf/2401.12345v1.pdf")
+	_, ok := out["links"]
+	is.True(!ok)
+}
+
+func TestSchema_InvalidFieldName(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := createMockArxivServer(t, mockArxivResponse)
+	defer server.Close()
+
+	err := openTestSourceErr(ctx, t, server.URL, map[string]string{
+		"sdk.schema.extract.payload.enabled": "true",
+		"field_mapping.to_payload":           "arxiv.title",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `payload field "arxiv.title" is not a valid Avro field name`))
+}
@@ -0,0 +1,286 @@
+package arxiv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"golang.org/x/time/rate"
+)
+
+// shardDateLayout is the minute precision date format of submittedDate
+// ranges.
+const shardDateLayout = "200601021504"
+
+// ShardingConfig configures reading the results of the query in
+// submittedDate windows. arXiv doesn't page past a fixed number of results,
+// so broad queries can only be read completely in windows small enough to
+// stay below it.
+type ShardingConfig struct {
+	// Enabled reads the results in submittedDate windows, in ascending order.
+	// sort_by and sort_order are ignored.
+	Enabled bool `json:"enabled" default:"false"`
+	// Start is the submission date (YYYY-MM-DD) the first window starts at.
+	Start string `json:"start" default:"1991-08-01"`
+	// Window is the size of a window. Windows with more results than
+	// ResultCap are bisected until they fit.
+	Window time.Duration `json:"window" default:"720h"`
+	// ResultCap is the maximum number of results arXiv returns for a query.
+	ResultCap int `json:"result_cap" default:"30000"`
+	// Overlap is how far windows reaching the present start before the end
+	// of the last window. Papers only show up once they are announced, usually
+	// a day or more after their submission, so they are missed if it is
+	// shorter than that. Papers read again are skipped, across restarts only
+	// if state_file is set.
+	Overlap time.Duration `json:"overlap" default:"48h"`
+	// RequestInterval is the minimum time between two requests made while
+	// looking for a window that fits the result cap or is not empty.
+	RequestInterval time.Duration `json:"request_interval" default:"3s"`
+}
+
+func (c ShardingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, c.Start); err != nil {
+		return fmt.Errorf("sharding.start %q must be a date in the format YYYY-MM-DD", c.Start)
+	}
+	if c.Window < time.Minute {
+		return fmt.Errorf("sharding.window must be at least 1m")
+	}
+	if c.ResultCap <= 0 {
+		return fmt.Errorf("sharding.result_cap must be greater than 0")
+	}
+	if c.Overlap < 0 {
+		return fmt.Errorf("sharding.overlap must not be negative")
+	}
+	if c.RequestInterval < 0 {
+		return fmt.Errorf("sharding.request_interval must not be negative")
+	}
+	return nil
+}
+
+// shardPosition is the position of a record read in sharded mode.
+type shardPosition struct {
+	// Completed is the time up to which all windows were read.
+	Completed time.Time `json:"completed"`
+	// Start and End delimit the window the record was read from.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Offset is the offset of the record in the window.
+	Offset int `json:"offset"`
+}
+
+// sharder keeps track of the submittedDate windows. Windows are half-open
+// intervals [start, end) aligned to minutes.
+type sharder struct {
+	maxSize   time.Duration
+	overlap   time.Duration
+	resultCap int
+	limiter   *rate.Limiter
+
+	// completed is the time up to which all windows were read.
+	completed time.Time
+	// start and end delimit the current window, active is false if there is
+	// none.
+	start, end time.Time
+	active     bool
+	// size is the size of the next window. It shrinks when windows are
+	// bisected and grows back when they have few results.
+	size time.Duration
+	// emitted holds the submission times of the entries read from windows
+	// that later windows overlap, by versioned ID.
+	emitted map[string]time.Time
+}
+
+// newSharder creates a sharder resuming at pos and returns the offset in the
+// current window.
+func newSharder(ctx context.Context, c ShardingConfig, pos opencdc.Position) (*sharder, int, error) {
+	start, err := time.Parse(time.DateOnly, c.Start)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid sharding.start: %w", err)
+	}
+	limit := rate.Inf
+	if c.RequestInterval > 0 {
+		limit = rate.Every(c.RequestInterval)
+	}
+	sh := &sharder{
+		maxSize:   c.Window,
+		overlap:   c.Overlap,
+		resultCap: c.ResultCap,
+		limiter:   rate.NewLimiter(limit, 1),
+		completed: start,
+		size:      c.Window,
+		emitted:   make(map[string]time.Time),
+	}
+	if len(pos) == 0 {
+		return sh, 0, nil
+	}
+
+	var p shardPosition
+	if err := json.Unmarshal(regularPosition(pos), &p); err != nil {
+		sdk.Logger(ctx).Warn().
+			Str("position", string(pos)).
+			Msg("position was not created in sharded mode, starting from sharding.start")
+		return sh, 0, nil
+	}
+	sh.completed = p.Completed
+	sh.start, sh.end, sh.active = p.Start, p.End, true
+	return sh, p.Offset, nil
+}
+
+// position returns the position of the result at offset in the current
+// window.
+func (sh *sharder) position(offset int) opencdc.Position {
+	b, _ := json.Marshal(shardPosition{
+		Completed: sh.completed,
+		Start:     sh.start,
+		End:       sh.end,
+		Offset:    offset,
+	})
+	return b
+}
+
+// next starts the window following the completed ones. It returns false if
+// there is nothing new to read yet. Without an overlap that is the case until
+// the next minute starts, with one the overlap is read again.
+func (sh *sharder) next(now time.Time) bool {
+	now = now.UTC().Truncate(time.Minute)
+	if !now.After(sh.completed) && sh.overlap == 0 {
+		return false
+	}
+	sh.start = sh.completed
+	if sh.overlap > 0 && now.Add(-sh.overlap).Before(sh.start) {
+		sh.start = now.Add(-sh.overlap).Truncate(time.Minute)
+	}
+	for id, submitted := range sh.emitted {
+		if submitted.Before(sh.start) {
+			delete(sh.emitted, id)
+		}
+	}
+	sh.end = sh.completed.Add(sh.size).Truncate(time.Minute)
+	if !sh.end.After(sh.completed) {
+		sh.end = sh.completed.Add(time.Minute)
+	}
+	if sh.end.After(now) {
+		sh.end = now
+	}
+	sh.active = true
+	return true
+}
+
+// duplicate reports whether an entry was already read from an earlier window
+// overlapping the current one.
+func (sh *sharder) duplicate(entry *ArxivEntry) bool {
+	if sh.overlap == 0 {
+		return false
+	}
+	if _, ok := sh.emitted[entry.ID]; ok {
+		return true
+	}
+	sh.emitted[entry.ID] = entry.Published
+	return false
+}
+
+// bisect halves the current window. It returns false if the window can't be
+// split any further.
+func (sh *sharder) bisect() bool {
+	mid := sh.start.Add(sh.end.Sub(sh.start) / 2).Truncate(time.Minute)
+	if !mid.After(sh.start) || !mid.After(sh.completed) {
+		return false
+	}
+	sh.end = mid
+	sh.size = mid.Sub(sh.start)
+	return true
+}
+
+// complete marks the current window as read. Windows with few results let
+// the next window grow.
+func (sh *sharder) complete(total int) {
+	if sh.end.After(sh.completed) {
+		sh.completed = sh.end
+	}
+	sh.active = false
+	if total < sh.resultCap/2 {
+		sh.size = min(sh.size*2, sh.maxSize)
+	}
+}
+
+// query restricts a search query to the current window.
+func (sh *sharder) query(searchQuery string) string {
+	return fmt.Sprintf("(%s) AND submittedDate:[%s TO %s]", searchQuery,
+		sh.start.Format(shardDateLayout), sh.end.Add(-time.Minute).Format(shardDateLayout))
+}
+
+// fetchShardPage fetches the next page of the current window. Windows that
+// exceed the result cap are bisected and empty windows are skipped before
+// a page is returned.
+func (s *Source) fetchShardPage(ctx context.Context) ([]opencdc.Record, error) {
+	sh := s.shards
+	for first := true; ; first = false {
+		if !sh.active {
+			if !sh.next(time.Now()) {
+				return nil, nil
+			}
+			s.offset = 0
+		}
+		// the first request was already rate limited by the polling period
+		if !first {
+			if err := sh.limiter.Wait(ctx); err != nil {
+				return nil, err //nolint:wrapcheck // context errors are returned as is
+			}
+		}
+
+		sdk.Logger(ctx).Debug().
+			Time("start", sh.start).
+			Time("end", sh.end).
+			Int("offset", s.offset).
+			Msg("fetching page of arXiv window")
+		apiURL, err := s.config.BuildArxivURL(sh.query(s.config.SearchQuery), "submittedDate", "ascending", s.offset, s.config.MaxResults)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build arXiv URL: %w", err)
+		}
+		feed, err := s.fetchFeed(ctx, apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		// a total at the cap may be the cap itself, only the first page is
+		// checked so results that were read stay in the window
+		if s.offset == 0 && feed.TotalResults >= sh.resultCap {
+			if sh.bisect() {
+				sdk.Logger(ctx).Debug().
+					Int("total", feed.TotalResults).
+					Time("end", sh.end).
+					Msg("bisected arXiv window exceeding the result cap")
+				continue
+			}
+			sdk.Logger(ctx).Warn().
+				Int("total", feed.TotalResults).
+				Time("start", sh.start).
+				Msg("arXiv window can't be split below the result cap, results past it are skipped")
+		}
+
+		records, err := s.convertEntries(ctx, feed.Entries)
+		if err != nil {
+			return nil, err
+		}
+		s.offset += len(feed.Entries)
+
+		if len(feed.Entries) < s.config.MaxResults {
+			sdk.Logger(ctx).Debug().
+				Time("start", sh.start).
+				Time("end", sh.end).
+				Int("results", s.offset).
+				Msg("completed arXiv window")
+			sh.complete(feed.TotalResults)
+			if len(feed.Entries) == 0 {
+				continue
+			}
+		}
+		return records, nil
+	}
+}
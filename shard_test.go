@@ -0,0 +1,127 @@
+package arxiv_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func shardCorpus() []arxivtest.Paper {
+	papers := make([]arxivtest.Paper, 10)
+	for i := range papers {
+		papers[i] = arxivtest.Paper{
+			ID:         fmt.Sprintf("2401.%05d", i+1),
+			Title:      fmt.Sprintf("Paper %d", i+1),
+			Summary:    "An abstract.",
+			Authors:    []string{"Jane Doe"},
+			Published:  time.Date(2024, 1, 1+2*i, 12, 0, 0, 0, time.UTC),
+			Categories: []string{"cs.LG"},
+		}
+	}
+	return papers
+}
+
+func shardConfig() map[string]string {
+	return map[string]string{
+		"search_query":              "cat:cs.LG",
+		"polling_period":            "10ms",
+		"max_results":               "2",
+		"sharding.enabled":          "true",
+		"sharding.start":            "2024-01-01",
+		"sharding.result_cap":       "3",
+		"sharding.request_interval": "0s",
+	}
+}
+
+func TestSource_Sharding(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(shardCorpus(), arxivtest.WithResultCap(3))
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), shardConfig())
+	recs := readN(ctx, t, src, 10)
+
+	// all papers are read once, in submission order, although the query
+	// matches more papers than the result cap
+	for i, rec := range recs {
+		is.Equal(rec.Metadata["arxiv.id"], fmt.Sprintf("2401.%05dv1", i+1))
+	}
+	for _, params := range server.Requests() {
+		is.True(strings.Contains(params.Get("search_query"), "submittedDate:["))
+		is.Equal(params.Get("sortBy"), "submittedDate")
+		is.Equal(params.Get("sortOrder"), "ascending")
+	}
+}
+
+func TestSource_ShardingResume(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(shardCorpus(), arxivtest.WithResultCap(3))
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), shardConfig())
+	recs := readN(ctx, t, src, 5)
+	is.NoErr(src.Teardown(ctx))
+
+	resumed := arxiv.NewSource()
+	is.NoErr(configureTestSource(ctx, resumed, server.URL(), shardConfig()))
+	is.NoErr(resumed.Open(ctx, recs[4].Position))
+	t.Cleanup(func() { _ = resumed.Teardown(context.Background()) })
+
+	// the record at the position is read again, like in offset mode
+	rest := readN(ctx, t, resumed, 6)
+	for i, rec := range rest {
+		is.Equal(rec.Metadata["arxiv.id"], fmt.Sprintf("2401.%05dv1", i+5))
+	}
+}
+
+func TestSource_ShardingLateAnnouncement(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	papers := []arxivtest.Paper{{
+		ID:         "2401.00001",
+		Published:  now.Add(-2 * time.Hour),
+		Categories: []string{"cs.LG"},
+	}}
+	server := arxivtest.NewServer(papers)
+	t.Cleanup(server.Close)
+
+	cfg := shardConfig()
+	cfg["sharding.start"] = now.Add(-72 * time.Hour).Format(time.DateOnly)
+	src := openTestSource(ctx, t, server.URL(), cfg)
+	recs := readN(ctx, t, src, 1)
+	is.Equal(recs[0].Metadata["arxiv.id"], "2401.00001v1")
+
+	// a paper submitted before the end of the completed windows is only
+	// announced now, the overlap reads it without repeating the other one
+	server.Add(arxivtest.Paper{
+		ID:         "2401.00002",
+		Published:  now.Add(-time.Hour),
+		Categories: []string{"cs.LG"},
+	})
+	recs = readN(ctx, t, src, 1)
+	is.Equal(recs[0].Metadata["arxiv.id"], "2401.00002v1")
+}
+
+func TestSourceConfig_ShardingStart(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	err := openTestSourceErr(ctx, t, "http://localhost", map[string]string{
+		"sharding.enabled": "true",
+		"sharding.start":   "01/01/2024",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "sharding.start"))
+}
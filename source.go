@@ -2,29 +2,52 @@ package arxiv
 
 import (
 	"context"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/conduitio/conduit-connector-sdk/schema"
 	"golang.org/x/time/rate"
 )
 
 // ArxivEntry represents a single paper entry from arXiv
 type ArxivEntry struct {
-	ID        string     `xml:"id"`
-	Title     string     `xml:"title"`
-	Summary   string     `xml:"summary"`
-	Authors   []Author   `xml:"author"`
-	Published time.Time  `xml:"published"`
-	Updated   time.Time  `xml:"updated"`
-	Links     []Link     `xml:"link"`
-	Category  []Category `xml:"category"`
+	ID        string     `xml:"id" json:"id"`
+	Title     string     `xml:"title" json:"title"`
+	Summary   string     `xml:"summary" json:"summary"`
+	Authors   []Author   `xml:"author" json:"authors"`
+	Published time.Time  `xml:"published" json:"published"`
+	Updated   time.Time  `xml:"updated" json:"updated"`
+	Links     []Link     `xml:"link" json:"links"`
+	Category  []Category `xml:"category" json:"categories"`
+
+	PrimaryCategory Category `xml:"http://arxiv.org/schemas/atom primary_category" json:"primary_category"`
+	Comment         string   `xml:"http://arxiv.org/schemas/atom comment" json:"comment,omitempty"`
+	JournalRef      string   `xml:"http://arxiv.org/schemas/atom journal_ref" json:"journal_ref,omitempty"`
+	DOI             string   `xml:"http://arxiv.org/schemas/atom doi" json:"doi,omitempty"`
+
+	// Versions and License are not part of the API response, they are only
+	// set if the version history is fetched.
+	Versions []Version `xml:"-" json:"versions,omitempty"`
+	License  string    `xml:"-" json:"license,omitempty"`
+
+	// withdrawnVersion is set if the version history shows that the latest
+	// version withdrew the paper.
+	withdrawnVersion bool
+	// unmatched is set if a reconciled paper no longer matches the search
+	// query.
+	unmatched bool
+
+	// raw contains the original <entry> element as returned by the API.
+	raw []byte
 }
 
 func (e *ArxivEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -37,39 +60,70 @@ func (e *ArxivEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 	if err := d.DecodeElement(tmp, &start); err != nil {
 		return err
 	}
+	// Missing dates are left zero, the error entries of the API have no
+	// published date
 	var err error
-	e.Published, err = time.Parse(time.RFC3339, tmp.Published)
-	if err != nil {
-		// Try parsing without timezone (arXiv sometimes omits 'Z')
-		e.Published, err = time.Parse("2006-01-02T15:04:05", tmp.Published)
+	if tmp.Published != "" {
+		e.Published, err = time.Parse(time.RFC3339, tmp.Published)
 		if err != nil {
-			return fmt.Errorf("failed to parse published date: %w", err)
+			// Try parsing without timezone (arXiv sometimes omits 'Z')
+			e.Published, err = time.Parse("2006-01-02T15:04:05", tmp.Published)
+			if err != nil {
+				return fmt.Errorf("failed to parse published date: %w", err)
+			}
 		}
 	}
-	e.Updated, err = time.Parse(time.RFC3339, tmp.Updated)
-	if err != nil {
-		// Try parsing without timezone
-		e.Updated, err = time.Parse("2006-01-02T15:04:05", tmp.Updated)
+	if tmp.Updated != "" {
+		e.Updated, err = time.Parse(time.RFC3339, tmp.Updated)
 		if err != nil {
-			return fmt.Errorf("failed to parse updated date: %w", err)
+			// Try parsing without timezone
+			e.Updated, err = time.Parse("2006-01-02T15:04:05", tmp.Updated)
+			if err != nil {
+				return fmt.Errorf("failed to parse updated date: %w", err)
+			}
 		}
 	}
+	// Normalize to UTC, keeping sub-second precision
+	e.Published = e.Published.UTC()
+	e.Updated = e.Updated.UTC()
 	return nil
 }
 
+// EventTime returns the time of the latest change to the entry, which is
+// the time the latest version was submitted.
+func (e *ArxivEntry) EventTime() time.Time {
+	if !e.Updated.IsZero() {
+		return e.Updated
+	}
+	return e.Published
+}
+
+// PrimaryCategoryTerm returns the primary category of the entry, falling back
+// to the first listed category if arXiv did not report one.
+func (e *ArxivEntry) PrimaryCategoryTerm() string {
+	if e.PrimaryCategory.Term != "" {
+		return e.PrimaryCategory.Term
+	}
+	if len(e.Category) > 0 {
+		return e.Category[0].Term
+	}
+	return ""
+}
+
 type Author struct {
-	Name string `xml:"name"`
+	Name string `xml:"name" json:"name"`
 }
 
 type Link struct {
-	Href string `xml:"href,attr"`
-	Type string `xml:"type,attr"`
-	Rel  string `xml:"rel,attr"`
+	Href  string `xml:"href,attr" json:"href"`
+	Type  string `xml:"type,attr" json:"type,omitempty"`
+	Rel   string `xml:"rel,attr" json:"rel"`
+	Title string `xml:"title,attr" json:"title,omitempty"`
 }
 
 type Category struct {
-	Term   string `xml:"term,attr"`
-	Scheme string `xml:"scheme,attr"`
+	Term   string `xml:"term,attr" json:"term"`
+	Scheme string `xml:"scheme,attr" json:"scheme"`
 }
 
 // ArxivFeed represents the XML response from arXiv API
@@ -77,6 +131,10 @@ type ArxivFeed struct {
 	XMLName xml.Name      `xml:"feed"`
 	Entries []*ArxivEntry `xml:"entry"`
 	Title   string        `xml:"title"`
+
+	// TotalResults is the number of results matching the query, as reported
+	// by OpenSearch.
+	TotalResults int `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
 }
 
 type Source struct {
@@ -85,10 +143,38 @@ type Source struct {
 	config  SourceConfig
 	client  *http.Client
 	limiter *rate.Limiter
-
-	buffer       []opencdc.Record
-	lastPosition opencdc.Position
-	offset       int
+	mapper  *fieldMapper
+	filters entryFilters
+
+	// versions fetches version histories, nil if disabled.
+	versions *versionFetcher
+
+	// state keeps the emitted papers, nil if no state file is configured.
+	state *stateStore
+	// reconciler re-fetches emitted papers, nil if reconciliation is
+	// disabled.
+	reconciler *reconciler
+
+	// shards splits the query into submittedDate windows, nil if sharding
+	// is disabled.
+	shards *sharder
+
+	// payloadSchema is the typed schema attached to every record, nil if
+	// typed schemas are disabled.
+	payloadSchema *paperSchema
+	schema        schema.Schema
+
+	buffer []opencdc.Record
+	acks   *ackTracker
+	pages  <-chan page
+
+	// cancel stops all background work started in Open, wg is used to wait
+	// for it to finish.
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// offset is only accessed by the fetcher goroutine once the source is open
+	offset int
 }
 
 type SourceConfig struct {
@@ -114,10 +200,70 @@ type SourceConfig struct {
 	// IncludePDF determines if PDF URLs should be included in the output
 	IncludePDF bool `json:"include_pdf" default:"true"`
 
-	// FilterLast24Hours only fetches papers from the last 24 hours
+	// FilterLast24Hours only fetches papers from the last 24 hours. Deprecated:
+	// use filter.published_within instead.
 	FilterLast24Hours bool `json:"filter_last_24_hours" default:"false"`
+
+	// Filter contains filters evaluated on every entry after it is fetched
+	Filter FilterConfig `json:"filter"`
+
+	// FieldMapping controls renaming, dropping and moving of record fields
+	FieldMapping FieldMappingConfig `json:"field_mapping"`
+
+	// TypedSchema attaches the connector's versioned paper schema to the
+	// payload instead of inferring one, if payload schema extraction is enabled
+	TypedSchema bool `json:"typed_schema" default:"true"`
+
+	// HTTP configures the client used to talk to the arXiv API
+	HTTP HTTPConfig `json:"http"`
+
+	// Cache configures an optional on-disk cache of API responses
+	Cache CacheConfig `json:"cache"`
+
+	// Fixtures configures recording and replaying of HTTP fixtures
+	Fixtures FixturesConfig `json:"fixtures"`
+
+	// PrefetchPages is the number of pages fetched ahead of the records being read
+	PrefetchPages int `json:"prefetch_pages" default:"1" validate:"gt=0"`
+
+	// VersionHistory configures fetching the version history and license of
+	// every paper
+	VersionHistory VersionHistoryConfig `json:"version_history"`
+
+	// WithdrawnMode determines how withdrawn papers are emitted: delete emits
+	// a delete record keyed on the versionless ID, flag emits a regular
+	// record with the withdrawn field set
+	WithdrawnMode string `json:"withdrawn_mode" default:"delete"`
+
+	// StateFile is the file in which the state of emitted papers is
+	// persisted. If set, re-fetched papers that didn't change and repeated
+	// deletes are skipped, and changed papers are emitted as updates. States
+	// are appended to it as JSON lines and it is compacted when most of them
+	// are outdated
+	StateFile string `json:"state_file"`
+
+	// Sharding configures reading the results in submittedDate windows, to
+	// get past the maximum number of results arXiv returns for a query
+	Sharding ShardingConfig `json:"sharding"`
+
+	// Reconcile configures the periodic re-fetching of emitted papers to
+	// detect changes
+	Reconcile ReconcileConfig `json:"reconcile"`
+
+	// Citations lists the citation formats (bibtex, ris, csl_json) rendered
+	// into the citation_<format> payload fields
+	Citations []string `json:"citations"`
+
+	// OutputFormat determines the payload format (structured, raw_xml, json)
+	OutputFormat string `json:"output_format" default:"structured"`
 }
 
+const (
+	OutputFormatStructured = "structured"
+	OutputFormatRawXML     = "raw_xml"
+	OutputFormatJSON       = "json"
+)
+
 func (s *SourceConfig) Validate(ctx context.Context) error {
 	// Validate the configuration
 	if err := s.DefaultSourceMiddleware.Validate(ctx); err != nil {
@@ -149,6 +295,59 @@ func (s *SourceConfig) Validate(ctx context.Context) error {
 		return fmt.Errorf("sort_order must be either ascending or descending")
 	}
 
+	validOutputFormat := map[string]bool{
+		OutputFormatStructured: true,
+		OutputFormatRawXML:     true,
+		OutputFormatJSON:       true,
+	}
+	if !validOutputFormat[s.OutputFormat] {
+		return fmt.Errorf("output_format must be one of: structured, raw_xml, json")
+	}
+
+	if s.WithdrawnMode != WithdrawnModeDelete && s.WithdrawnMode != WithdrawnModeFlag {
+		return fmt.Errorf("withdrawn_mode must be either delete or flag")
+	}
+
+	if err := validateQueryCategories(s.SearchQuery); err != nil {
+		return err
+	}
+
+	if err := s.HTTP.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Cache.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Fixtures.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.VersionHistory.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Sharding.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Reconcile.Validate(s.StateFile); err != nil {
+		return err
+	}
+
+	if err := validateCitationFormats(s.Citations); err != nil {
+		return err
+	}
+
+	if err := s.Filter.Validate(s.SearchQuery); err != nil {
+		return err
+	}
+
+	if err := s.FieldMapping.Validate(); err != nil {
+		return fmt.Errorf("invalid field_mapping: %w", err)
+	}
+
 	return nil
 }
 
@@ -163,53 +362,130 @@ func (s *Source) Config() sdk.SourceConfig {
 func (s *Source) Open(ctx context.Context, pos opencdc.Position) error {
 	sdk.Logger(ctx).Info().Msg("opening arXiv source")
 
-	s.client = &http.Client{
-		Timeout: 30 * time.Second,
+	client, err := newHTTPClient(s.config.HTTP, s.config.Cache, s.config.Fixtures)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP client: %w", err)
 	}
+	s.client = client
 
 	// Set up rate limiter based on polling period
 	s.limiter = rate.NewLimiter(rate.Every(s.config.PollingPeriod), 1)
 
+	s.mapper = newFieldMapper(s.config.FieldMapping)
+
+	if s.config.VersionHistory.Enabled {
+		s.versions = newVersionFetcher(client, s.config.VersionHistory, s.config.HTTP.MaxResponseSize)
+	}
+
+	s.filters, err = newEntryFilters(s.config.Filter, s.config.FilterLast24Hours, s.config.SearchQuery, time.Now)
+	if err != nil {
+		return err
+	}
+
+	if s.config.TypedSchema && *s.config.PayloadEnabled && s.config.OutputFormat == OutputFormatStructured {
+		err := s.createPayloadSchema(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Parse position to get offset
-	if len(pos) > 0 {
-		if offset, err := strconv.Atoi(string(pos)); err == nil {
+	if s.config.Sharding.Enabled {
+		s.shards, s.offset, err = newSharder(ctx, s.config.Sharding, pos)
+		if err != nil {
+			return err
+		}
+	} else if len(pos) > 0 {
+		if offset, ok := parseOffset(pos); ok {
 			s.offset = offset
 		}
 	}
 
-	s.lastPosition = pos
+	s.acks = newAckTracker(pos)
+
+	if s.config.StateFile != "" {
+		s.state, err = loadStateStore(s.config.StateFile)
+		if err != nil {
+			return err
+		}
+		s.acks.OnCommit(s.state.commit)
+	}
+	if s.config.Reconcile.Interval > 0 {
+		s.reconciler = newReconciler(s.config.Reconcile)
+	}
+
+	// Background work is bound to a source-level context, so Teardown can
+	// interrupt in-flight requests and rate limiter waits.
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.startFetcher(ctx)
+	return nil
+}
+
+// createPayloadSchema registers the typed paper schema with the schema
+// service.
+func (s *Source) createPayloadSchema(ctx context.Context) error {
+	ps, err := newPaperSchema(s.mapper)
+	if err != nil {
+		return err
+	}
+	b, err := ps.Bytes()
+	if err != nil {
+		return err
+	}
+
+	sch, err := schema.Create(ctx, schema.TypeAvro, *s.config.PayloadSubject, b)
+	if err != nil {
+		return fmt.Errorf("failed to create payload schema: %w", err)
+	}
+	sdk.Logger(ctx).Info().
+		Str("subject", sch.Subject).
+		Int("version", sch.Version).
+		Msg("registered typed payload schema")
+
+	s.payloadSchema = ps
+	s.schema = sch
 	return nil
 }
 
 func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
-	if len(s.buffer) == 0 {
-		// Wait for rate limiter
-		err := s.limiter.Wait(ctx)
-		if err != nil {
-			return opencdc.Record{}, err
-		}
+	recs, err := s.ReadN(ctx, 1)
+	if err != nil {
+		return opencdc.Record{}, err
+	}
+	return recs[0], nil
+}
 
-		// Fill buffer with new records
-		err = s.fillBuffer(ctx)
+func (s *Source) ReadN(ctx context.Context, n int) ([]opencdc.Record, error) {
+	if len(s.buffer) == 0 {
+		// Take the next page fetched in the background
+		records, err := s.nextPage(ctx)
 		if err != nil {
-			return opencdc.Record{}, err
+			return nil, err
 		}
+		s.buffer = records
 	}
 
 	if len(s.buffer) == 0 {
-		return opencdc.Record{}, sdk.ErrBackoffRetry
+		return nil, sdk.ErrBackoffRetry
 	}
 
-	// Return the first record from buffer
-	rec := s.buffer[0]
-	s.buffer = s.buffer[1:]
-	s.lastPosition = rec.Position
+	// Return up to n records from buffer
+	n = min(n, len(s.buffer))
+	recs := s.buffer[:n:n]
+	s.buffer = s.buffer[n:]
+	for _, rec := range recs {
+		s.acks.Track(rec.Position)
+	}
 
-	return rec, nil
+	return recs, nil
 }
 
-func (s *Source) fillBuffer(ctx context.Context) error {
-	sdk.Logger(ctx).Debug().Msg("filling buffer with arXiv entries")
+// fetchPage fetches the next page of entries and converts them to records.
+func (s *Source) fetchPage(ctx context.Context) ([]opencdc.Record, error) {
+	if s.shards != nil {
+		return s.fetchShardPage(ctx)
+	}
+	sdk.Logger(ctx).Debug().Int("offset", s.offset).Msg("fetching page of arXiv entries")
 
 	// Build arXiv API URL
 	apiURL, err := s.config.BuildArxivURL(
@@ -220,66 +496,149 @@ func (s *Source) fillBuffer(ctx context.Context) error {
 		s.config.MaxResults,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to build arXiv URL: %w", err)
+		return nil, fmt.Errorf("failed to build arXiv URL: %w", err)
 	}
 
-	// Make request to arXiv API
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	feed, err := s.fetchFeed(ctx, apiURL)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Conduit-ArXiv-Connector/1.0")
+	records, err := s.convertEntries(ctx, feed.Entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update offset for next request
+	s.offset += len(feed.Entries)
+
+	return records, nil
+}
+
+// convertEntries converts the entries of a page starting at the current
+// offset to records, leaving out filtered and unchanged entries.
+func (s *Source) convertEntries(ctx context.Context, entries []*ArxivEntry) ([]opencdc.Record, error) {
+	records := make([]opencdc.Record, 0, len(entries))
+	filtered := make(map[string]int)
+	unchanged, invalid := 0, 0
+	for i, entry := range entries {
+		// a single malformed entry must not stop reading the results
+		if _, err := ParseIdentifier(entry.ID); err != nil {
+			sdk.Logger(ctx).Warn().Err(err).Str("entry", entry.ID).Msg("skipping arXiv entry with invalid identifier")
+			invalid++
+			continue
+		}
+
+		// without a state file, papers read again in an overlapping window
+		// would be emitted twice
+		if s.shards != nil && s.state == nil && s.shards.duplicate(entry) {
+			unchanged++
+			continue
+		}
+
+		if s.versions != nil {
+			if err := s.versions.annotate(ctx, entry); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				sdk.Logger(ctx).Warn().Err(err).Str("entry", entry.ID).Msg("failed to fetch version history")
+			}
+		}
+
+		// deletes for withdrawn papers must reach the destination, even if
+		// the notice that replaced the paper doesn't pass the filters. The
+		// version history is checked too, so this runs after annotating.
+		deleted := s.config.WithdrawnMode == WithdrawnModeDelete && isWithdrawn(entry)
+		if name := s.filters.apply(entry); name != "" && !deleted {
+			filtered[name]++
+			continue
+		}
+
+		position := s.position(s.offset + i)
+		rec, st, err := s.entryToRecord(*entry, position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert entry to record: %w", err)
+		}
+		if s.state != nil {
+			changed, err := s.detectChange(&rec, st)
+			if err != nil {
+				return nil, err
+			}
+			if !changed {
+				unchanged++
+				continue
+			}
+			s.state.stage(position, st)
+		}
+		records = append(records, rec)
+	}
+
+	if unchanged > 0 {
+		sdk.Logger(ctx).Debug().Int("unchanged", unchanged).Msg("skipped unchanged arXiv entries")
+	}
+
+	if len(filtered) > 0 {
+		sdk.Logger(ctx).Info().
+			Int("fetched", len(entries)).
+			Int("filtered", len(entries)-len(records)-unchanged-invalid).
+			Interface("filtered_by", filtered).
+			Msg("filtered arXiv entries")
+	}
+
+	return records, nil
+}
+
+// position returns the position of the result at offset. In sharded mode
+// the position also contains the current window.
+func (s *Source) position(offset int) opencdc.Position {
+	if s.shards != nil {
+		return s.shards.position(offset)
+	}
+	return opencdc.Position(strconv.Itoa(offset))
+}
+
+// fetchFeed requests a feed from the arXiv API and parses it.
+func (s *Source) fetchFeed(ctx context.Context, apiURL string) (*ArxivFeed, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to fetch from arXiv: %w", err)
+		return nil, fmt.Errorf("failed to fetch from arXiv: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("arXiv API returned status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("arXiv API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Parse XML response
-	body, err := io.ReadAll(resp.Body)
+	body, err := readAllLimited(resp.Body, s.config.HTTP.MaxResponseSize)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var feed ArxivFeed
-	err = xml.Unmarshal(body, &feed)
+	feed, err := parseFeed(body)
 	if err != nil {
-		return fmt.Errorf("failed to parse XML response: %w", err)
+		return nil, fmt.Errorf("failed to parse XML response: %w", err)
 	}
-
-	// Convert entries to OpenCDC records
-	for i, entry := range feed.Entries {
-		// Apply 24-hour filter if enabled
-		if s.config.FilterLast24Hours {
-			twentyFourHoursAgo := time.Now().UTC().Add(-24 * time.Hour)
-			if entry.Published.Before(twentyFourHoursAgo) {
-				continue
-			}
-		}
-
-		rec, err := s.entryToRecord(*entry, s.offset+i)
-		if err != nil {
-			return fmt.Errorf("failed to convert entry to record: %w", err)
-		}
-		s.buffer = append(s.buffer, rec)
+	if msg, ok := feed.apiError(); ok {
+		return nil, fmt.Errorf("arXiv API returned an error: %s", msg)
 	}
-
-	// Update offset for next request
-	s.offset += len(feed.Entries)
-
-	return nil
+	return feed, nil
 }
 
-func (s *Source) entryToRecord(entry ArxivEntry, position int) (opencdc.Record, error) {
-	// Extract arXiv ID from the entry ID URL
-	arxivID := extractArxivID(entry.ID)
+// entryToRecord converts an entry to a record and returns the state in which
+// it emits the paper.
+func (s *Source) entryToRecord(entry ArxivEntry, position opencdc.Position) (opencdc.Record, paperState, error) {
+	id, err := ParseIdentifier(entry.ID)
+	if err != nil {
+		return opencdc.Record{}, paperState{}, err
+	}
+	arxivID := id.String()
 
 	// Find PDF link if requested
 	var pdfURL string
@@ -298,65 +657,234 @@ func (s *Source) entryToRecord(entry ArxivEntry, position int) (opencdc.Record,
 		authors[i] = author.Name
 	}
 
-	// Extract categories
+	// Extract categories and their human-readable names
 	categories := make([]string, len(entry.Category))
+	categoryNames := make([]string, len(entry.Category))
 	for i, cat := range entry.Category {
 		categories[i] = cat.Term
+		categoryNames[i] = cat.Term
+		if info, ok := LookupCategory(cat.Term); ok {
+			categoryNames[i] = info.Name
+		}
 	}
+	primary, _ := LookupCategory(entry.PrimaryCategoryTerm())
+
+	// Extract links
+	links := make([]map[string]interface{}, len(entry.Links))
+	for i, link := range entry.Links {
+		links[i] = map[string]interface{}{
+			"href":  link.Href,
+			"rel":   link.Rel,
+			"type":  nullableString(link.Type),
+			"title": nullableString(link.Title),
+		}
+	}
+
+	resources := extractResourceLinks(entry.Summary, entry.Comment)
+	withdrawn := isWithdrawn(&entry)
 
 	// Create structured data
 	data := map[string]interface{}{
-		"arxiv_id":   arxivID,
-		"title":      entry.Title,
-		"abstract":   entry.Summary,
-		"authors":    authors,
-		"published":  entry.Published.Format(time.RFC3339),
-		"updated":    entry.Updated.Format(time.RFC3339),
-		"categories": categories,
-		"entry_url":  entry.ID,
+		"arxiv_id":        arxivID,
+		"arxiv_base_id":   id.Base(),
+		"title":           entry.Title,
+		"abstract":        entry.Summary,
+		"authors":         authors,
+		"published":       entry.Published.Format(time.RFC3339Nano),
+		"updated":         entry.Updated.Format(time.RFC3339Nano),
+		"categories":      categories,
+		"category_names":  categoryNames,
+		"archive":         nullableString(primary.Archive),
+		"group":           nullableString(primary.Group),
+		"links":           links,
+		"entry_url":       entry.ID,
+		"urls":            s.canonicalURLs(id, entry.PrimaryCategoryTerm()),
+		"arxiv_doi":       id.DOI(),
+		"doi":             nullableString(strings.TrimSpace(entry.DOI)),
+		"journal_ref":     nullableString(collapseSpace(entry.JournalRef)),
+		"journal":         journalRefData(entry.JournalRef),
+		"comment":         nullableString(collapseSpace(entry.Comment)),
+		"comment_details": commentData(entry.Comment),
+		"peer_reviewed":   peerReviewed(&entry),
+		"code_urls":       resources.code,
+		"dataset_urls":    resources.datasets,
+		"project_urls":    resources.projects,
+		"versions":        versionsData(entry.Versions),
+		"license":         nullableString(entry.License),
+		"withdrawn":       withdrawn,
 	}
 
 	if pdfURL != "" {
 		data["pdf_url"] = pdfURL
 	}
 
+	if len(s.config.Citations) > 0 {
+		c := newCitation(entry, id)
+		for _, format := range s.config.Citations {
+			rendered, err := c.render(format)
+			if err != nil {
+				return opencdc.Record{}, paperState{}, err
+			}
+			data[citationFields[format]] = rendered
+		}
+	}
+
+	// The state is taken before the field mapping changes the payload
+	st := paperState{id: id.Base()}
+	if s.state != nil {
+		st, err = newPaperState(id, data)
+		if err != nil {
+			return opencdc.Record{}, paperState{}, err
+		}
+	}
+
 	// Create metadata
 	meta := opencdc.Metadata{}
 	meta.SetReadAt(time.Now())
+	meta.SetCreatedAt(entry.EventTime())
 	meta["arxiv.id"] = arxivID
 	meta["arxiv.title"] = entry.Title
-	meta["arxiv.published"] = entry.Published.Format(time.RFC3339)
+	meta["arxiv.published"] = entry.Published.Format(time.RFC3339Nano)
+	meta["arxiv.updated"] = entry.Updated.Format(time.RFC3339Nano)
+	meta["arxiv.version"] = ""
+	if id.Version > 0 {
+		meta["arxiv.version"] = strconv.Itoa(id.Version)
+	}
+	meta["arxiv.primary_category"] = entry.PrimaryCategoryTerm()
+	if withdrawn {
+		meta["arxiv.withdrawn"] = "true"
+	}
+
+	key, err := s.mapper.apply(data, meta)
+	if err != nil {
+		return opencdc.Record{}, paperState{}, fmt.Errorf("failed to apply field mapping: %w", err)
+	}
+
+	if (withdrawn && s.config.WithdrawnMode == WithdrawnModeDelete) || entry.unmatched {
+		st.deleted = true
+		return opencdc.Record{
+			Operation: opencdc.OperationDelete,
+			Position:  position,
+			Key:       key,
+			Metadata:  meta,
+		}, st, nil
+	}
+
+	var payload opencdc.Data
+	switch s.config.OutputFormat {
+	case OutputFormatRawXML:
+		payload = opencdc.RawData(entry.raw)
+		st.RawPayload = payload.Bytes()
+	case OutputFormatJSON:
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return opencdc.Record{}, paperState{}, fmt.Errorf("failed to encode entry as JSON: %w", err)
+		}
+		payload = opencdc.RawData(b)
+		st.RawPayload = b
+	default:
+		if s.state != nil {
+			if st.Payload, err = json.Marshal(data); err != nil {
+				return opencdc.Record{}, paperState{}, fmt.Errorf("failed to encode payload state: %w", err)
+			}
+		}
+		if s.payloadSchema != nil {
+			if err := s.payloadSchema.typed(data); err != nil {
+				return opencdc.Record{}, paperState{}, fmt.Errorf("failed to convert payload to schema types: %w", err)
+			}
+		}
+		payload = opencdc.StructuredData(data)
+	}
 
-	return opencdc.Record{
+	rec := opencdc.Record{
 		Operation: opencdc.OperationCreate,
-		Position:  opencdc.Position(strconv.Itoa(position)),
-		Key:       opencdc.RawData(arxivID),
+		Position:  position,
+		Key:       key,
 		Payload: opencdc.Change{
-			After: opencdc.StructuredData(data),
+			After: payload,
 		},
 		Metadata: meta,
-	}, nil
+	}
+	if s.payloadSchema != nil {
+		schema.AttachPayloadSchemaToRecord(rec, s.schema)
+	}
+	return rec, st, nil
 }
 
-// extractArxivID extracts the arXiv ID from the entry ID URL
-func extractArxivID(entryID string) string {
-	// Entry ID format: http://arxiv.org/abs/1234.5678v1
-	parts := strings.Split(entryID, "/")
-	if len(parts) > 0 {
-		return parts[len(parts)-1]
+// canonicalURLs returns the links of a paper generated from its identifier,
+// so they are consistent regardless of the links reported by the API.
+func (s *Source) canonicalURLs(id Identifier, primaryCategory string) map[string]interface{} {
+	unversioned := id.WithVersion(0)
+	urls := map[string]interface{}{
+		"abs":            unversioned.AbsURL(),
+		"abs_versioned":  nil,
+		"pdf":            nil,
+		"pdf_versioned":  nil,
+		"html":           unversioned.HTMLURL(),
+		"html_versioned": nil,
+		"listing":        nil,
+	}
+	if s.config.IncludePDF {
+		urls["pdf"] = unversioned.PDFURL()
+	}
+	if id.Version > 0 {
+		urls["abs_versioned"] = id.AbsURL()
+		urls["html_versioned"] = id.HTMLURL()
+		if s.config.IncludePDF {
+			urls["pdf_versioned"] = id.PDFURL()
+		}
 	}
-	return entryID
+	if primaryCategory != "" {
+		urls["listing"] = id.ListingURL(primaryCategory)
+	}
+	return urls
+}
+
+// nullableString returns nil for empty strings, so that they are encoded as
+// null in the payload.
+func nullableString(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
 }
 
 func (s *Source) Ack(ctx context.Context, position opencdc.Position) error {
 	sdk.Logger(ctx).Debug().Str("position", string(position)).Msg("got ack")
-	return nil
+	return s.acks.Ack(ctx, position)
 }
 
 func (s *Source) Teardown(ctx context.Context) error {
 	sdk.Logger(ctx).Info().Msg("tearing down arXiv source")
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	// Wait for background work to stop
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for background work to stop: %w", ctx.Err())
+	}
+
+	if s.state != nil {
+		if err := s.state.close(); err != nil {
+			return err
+		}
+	}
+	if s.acks != nil {
+		sdk.Logger(ctx).Info().
+			Str("committed_position", string(s.acks.Committed())).
+			Int("in_flight", s.acks.InFlight()).
+			Msg("closing with committed position")
+	}
 	if s.client != nil {
-		// Close any connections if needed
+		s.client.CloseIdleConnections()
 	}
 	return nil
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
 	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
 )
 
 const mockArxivResponse = `<?xml version="1.0" encoding="UTF-8"?>
@@ -101,7 +102,7 @@ func TestSource_Read(t *testing.T) {
 
 	// Verify record structure
 	is.Equal(rec.Operation, opencdc.OperationCreate)
-	is.Equal(string(rec.Key.Bytes()), "2401.12345v1")
+	is.Equal(string(rec.Key.Bytes()), "2401.12345")
 
 	data, ok := rec.Payload.After.(opencdc.StructuredData)
 	if !ok {
@@ -429,3 +430,145 @@ func TestTeardownSource_NoOpen(t *testing.T) {
 	err := con.Teardown(context.Background())
 	is.NoErr(err)
 }
+
+// openTestSource configures and opens a source that reads from the given
+// server, using cfg to override or extend the default test configuration.
+func openTestSource(ctx context.Context, t *testing.T, serverURL string, cfg map[string]string) sdk.Source {
+	t.Helper()
+	is := is.New(t)
+
+	src := arxiv.NewSource()
+	is.NoErr(configureTestSource(ctx, src, serverURL, cfg))
+
+	is.NoErr(src.Open(ctx, nil))
+	t.Cleanup(func() { _ = src.Teardown(context.Background()) })
+	return src
+}
+
+// openTestSourceErr is like openTestSource, but returns the configuration or
+// open error instead of failing the test.
+func openTestSourceErr(ctx context.Context, t *testing.T, serverURL string, cfg map[string]string) error {
+	t.Helper()
+
+	src := arxiv.NewSource()
+	if err := configureTestSource(ctx, src, serverURL, cfg); err != nil {
+		return err
+	}
+	t.Cleanup(func() { _ = src.Teardown(context.Background()) })
+	return src.Open(ctx, nil)
+}
+
+func configureTestSource(ctx context.Context, src sdk.Source, serverURL string, cfg map[string]string) error {
+	settings := map[string]string{
+		"arxiv_api_url":                      serverURL,
+		"search_query":                       "AI",
+		"polling_period":                     "100ms",
+		"sdk.schema.extract.payload.enabled": "false",
+	}
+	for k, v := range cfg {
+		settings[k] = v
+	}
+	return sdk.Util.ParseConfig(ctx, settings, src.Config(), arxiv.Connector.NewSpecification().SourceParams)
+}
+
+func TestSource_Timestamps(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	response := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.12345v3</id>
+    <title>Sample Title</title>
+    <summary>Sample Summary</summary>
+    <published>2025-06-01T02:00:00.125+02:00</published>
+    <updated>2025-06-02T00:00:00.5Z</updated>
+    <arxiv:primary_category term="stat.ML" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.LG" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="stat.ML" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>`
+
+	server := createMockArxivServer(t, response)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, nil)
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	createdAt, err := rec.Metadata.GetCreatedAt()
+	is.NoErr(err)
+	is.Equal(createdAt.UTC(), time.Date(2025, 6, 2, 0, 0, 0, 500000000, time.UTC))
+
+	is.Equal(rec.Metadata["arxiv.published"], "2025-06-01T00:00:00.125Z")
+	is.Equal(rec.Metadata["arxiv.updated"], "2025-06-02T00:00:00.5Z")
+	is.Equal(rec.Metadata["arxiv.version"], "3")
+	is.Equal(rec.Metadata["arxiv.primary_category"], "stat.ML")
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(data["published"], "2025-06-01T00:00:00.125Z")
+}
+
+func TestSource_FakeAPI(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var papers []arxivtest.Paper
+	for i := range 5 {
+		papers = append(papers, arxivtest.Paper{
+			ID:         fmt.Sprintf("2401.%05d", i+1),
+			Title:      fmt.Sprintf("Paper %d", i+1),
+			Published:  time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC),
+			Categories: []string{"cs.LG"},
+		})
+	}
+	papers = append(papers, arxivtest.Paper{
+		ID:         "2401.00100",
+		Published:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Categories: []string{"hep-th"},
+	})
+	server := arxivtest.NewServer(papers)
+	t.Cleanup(server.Close)
+	server.InjectFaults(arxivtest.Fault{Delay: 50 * time.Millisecond})
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":   "cat:cs.LG",
+		"max_results":    "2",
+		"sort_order":     "ascending",
+		"polling_period": "1ms",
+	})
+
+	var keys []string
+	for range 5 {
+		rec, err := src.Read(ctx)
+		is.NoErr(err)
+		keys = append(keys, string(rec.Key.Bytes()))
+	}
+	is.Equal(keys, []string{"2401.00001", "2401.00002", "2401.00003", "2401.00004", "2401.00005"})
+
+	_, err := src.Read(ctx)
+	is.Equal(err, sdk.ErrBackoffRetry)
+
+	var starts []string
+	for _, r := range server.Requests() {
+		starts = append(starts, r.Get("start"))
+	}
+	is.Equal(starts[:4], []string{"0", "2", "4", "5"})
+}
+
+func TestSource_FakeAPIUnavailable(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(nil)
+	t.Cleanup(server.Close)
+	server.InjectFaults(arxivtest.Fault{Status: http.StatusServiceUnavailable, Body: "Rate exceeded."})
+
+	src := openTestSource(ctx, t, server.URL(), nil)
+
+	_, err := src.Read(ctx)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "arXiv API returned status 503: Rate exceeded."))
+}
@@ -0,0 +1,392 @@
+package arxiv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// paperState is the state in which a paper was last emitted.
+type paperState struct {
+	Version int `json:"version"`
+	// Hash is the hash of the payload before the field mapping was applied.
+	Hash string `json:"hash"`
+	// Fields contains the hash of every payload field, by original name.
+	Fields map[string]string `json:"fields"`
+	// Payload is the structured payload the paper was emitted with, after
+	// the field mapping and before the conversion to schema types. It is
+	// emitted as Before once the paper changes.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// RawPayload is the payload the paper was emitted with in the raw_xml
+	// and json output formats.
+	RawPayload []byte `json:"raw_payload,omitempty"`
+
+	// id is the versionless identifier of the paper.
+	id string
+	// deleted is set if the paper was emitted as a delete record.
+	deleted bool
+}
+
+// newPaperState returns the state of a paper emitted with the given payload.
+// The emitted payload is added once the field mapping was applied.
+func newPaperState(id Identifier, data map[string]interface{}) (paperState, error) {
+	fields := make(map[string]string, len(data))
+	for name, v := range data {
+		h, err := fieldHash(v)
+		if err != nil {
+			return paperState{}, fmt.Errorf("failed to hash field %q: %w", name, err)
+		}
+		fields[name] = h
+	}
+
+	// the payload hash is built from the sorted field hashes, so it ignores
+	// the same differences as they do
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sum := sha256.New()
+	for _, name := range names {
+		sum.Write([]byte(name + "=" + fields[name] + "\n"))
+	}
+
+	return paperState{
+		Version: id.Version,
+		Hash:    hex.EncodeToString(sum.Sum(nil)),
+		Fields:  fields,
+		id:      id.Base(),
+	}, nil
+}
+
+// stateCompactMinLines is the minimum number of lines of the state file
+// before it is compacted.
+const stateCompactMinLines = 1000
+
+// stateLine is a line of the state file. It either records the state a
+// paper was emitted in, the deletion of a paper or the time of a
+// reconciliation.
+type stateLine struct {
+	ID             string      `json:"id,omitempty"`
+	State          *paperState `json:"state,omitempty"`
+	Deleted        bool        `json:"deleted,omitempty"`
+	LastReconciled *time.Time  `json:"last_reconciled,omitempty"`
+}
+
+// storedState locates the latest state of a paper in the state file.
+type storedState struct {
+	hash   string
+	offset int64
+	length int
+	// deleted is set if the paper was last emitted as a delete record.
+	deleted bool
+}
+
+// stagedState is the state of a paper emitted in a record that was not yet
+// acknowledged.
+type stagedState struct {
+	position opencdc.Position
+	state    paperState
+}
+
+// stateStore keeps the set of emitted papers and the state they were emitted
+// in. The state of a paper only changes once the record emitting it is
+// committed, so it never gets ahead of the destination.
+//
+// States are appended to the state file as JSON lines, only their hashes and
+// locations are kept in memory. The file is compacted when it is opened and
+// whenever most of its lines are outdated.
+type stateStore struct {
+	mu sync.Mutex
+
+	path         string
+	file         *os.File
+	size         int64
+	lines        int
+	papers       map[string]storedState
+	reconciledAt time.Time
+	staged       []stagedState
+}
+
+// loadStateStore loads the state persisted at path. A missing file is an
+// empty state.
+func loadStateStore(path string) (*stateStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file: %w", err)
+	}
+	s := &stateStore{
+		path:         path,
+		file:         f,
+		papers:       make(map[string]storedState),
+		reconciledAt: time.Now(),
+	}
+	if err := s.read(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if s.outdated() {
+		if err := s.compact(); err != nil {
+			_ = s.file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// read indexes the lines of the state file. A partially written last line,
+// left by a crash, is cut off.
+func (s *stateStore) read() error {
+	r := bufio.NewReader(s.file)
+	var offset int64
+	for {
+		b, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(b) > 0 {
+				if err := s.file.Truncate(offset); err != nil {
+					return fmt.Errorf("failed to truncate state file: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read state file: %w", err)
+		}
+
+		var line stateLine
+		if err := json.Unmarshal(b, &line); err != nil {
+			return fmt.Errorf("failed to parse state file %q at offset %d: %w", s.path, offset, err)
+		}
+		s.index(line, offset, len(b))
+		offset += int64(len(b))
+	}
+	s.size = offset
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek state file: %w", err)
+	}
+	return nil
+}
+
+// index applies a line written at offset to the in-memory state.
+func (s *stateStore) index(line stateLine, offset int64, length int) {
+	s.lines++
+	switch {
+	case line.LastReconciled != nil:
+		s.reconciledAt = *line.LastReconciled
+	case line.Deleted:
+		// deleted papers are kept, so repeated deletes can be skipped
+		s.papers[line.ID] = storedState{offset: offset, length: length, deleted: true}
+	case line.State != nil:
+		s.papers[line.ID] = storedState{hash: line.State.Hash, offset: offset, length: length}
+	}
+}
+
+// appendLocked appends lines to the state file.
+func (s *stateStore) appendLocked(lines []stateLine) error {
+	var buf bytes.Buffer
+	offsets := make([]int64, len(lines))
+	lengths := make([]int, len(lines))
+	for i, line := range lines {
+		b, err := json.Marshal(line)
+		if err != nil {
+			return fmt.Errorf("failed to encode state: %w", err)
+		}
+		offsets[i] = s.size + int64(buf.Len())
+		lengths[i] = len(b) + 1
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	s.size += int64(buf.Len())
+	for i, line := range lines {
+		s.index(line, offsets[i], lengths[i])
+	}
+
+	if s.outdated() {
+		return s.compact()
+	}
+	return nil
+}
+
+// outdated reports whether most lines of the state file are outdated.
+func (s *stateStore) outdated() bool {
+	return s.lines > stateCompactMinLines && s.lines > 2*len(s.papers)
+}
+
+// compact rewrites the state file with only the latest state of every paper.
+func (s *stateStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to compact state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	papers := make(map[string]storedState, len(s.papers))
+	var size int64
+	for id, stored := range s.papers {
+		b := make([]byte, stored.length)
+		if _, err := s.file.ReadAt(b, stored.offset); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to read state of %s: %w", id, err)
+		}
+		_, _ = w.Write(b)
+		stored.offset = size
+		papers[id] = stored
+		size += int64(stored.length)
+	}
+	b, err := json.Marshal(stateLine{LastReconciled: &s.reconciledAt})
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	_, _ = w.Write(append(b, '\n'))
+	size += int64(len(b) + 1)
+
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to compact state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to compact state file: %w", err)
+	}
+	_ = s.file.Close()
+
+	s.file = tmp
+	s.size = size
+	s.lines = len(papers) + 1
+	s.papers = papers
+	return nil
+}
+
+// stage records the state a paper is emitted in with the record at position.
+// States need to be staged in the order in which the records are read.
+func (s *stateStore) stage(position opencdc.Position, st paperState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staged = append(s.staged, stagedState{position: position, state: st})
+}
+
+// commit persists the states staged up to and including the committed
+// position. It is registered as commit function of the ack tracker.
+func (s *stateStore) commit(_ context.Context, committed opencdc.Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for i, st := range s.staged {
+		if string(st.position) == string(committed) {
+			n = i + 1
+			break
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	lines := make([]stateLine, n)
+	for i, staged := range s.staged[:n] {
+		if staged.state.deleted {
+			lines[i] = stateLine{ID: staged.state.id, Deleted: true}
+		} else {
+			lines[i] = stateLine{ID: staged.state.id, State: &staged.state}
+		}
+	}
+	s.staged = s.staged[n:]
+	return s.appendLocked(lines)
+}
+
+// lookup returns the hash of the committed state of a paper and whether it
+// was deleted.
+func (s *stateStore) lookup(id string) (storedState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.papers[id]
+	return stored, ok
+}
+
+// load reads the committed state of a paper from the state file.
+func (s *stateStore) load(id string) (paperState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.papers[id]
+	if !ok || stored.deleted {
+		return paperState{}, fmt.Errorf("no state stored for %s", id)
+	}
+	b := make([]byte, stored.length)
+	if _, err := s.file.ReadAt(b, stored.offset); err != nil {
+		return paperState{}, fmt.Errorf("failed to read state of %s: %w", id, err)
+	}
+	var line stateLine
+	if err := json.Unmarshal(b, &line); err != nil || line.State == nil {
+		return paperState{}, fmt.Errorf("invalid state of %s at offset %d", id, stored.offset)
+	}
+	st := *line.State
+	st.id = id
+	return st, nil
+}
+
+// ids returns the sorted IDs of all committed papers that were not deleted.
+func (s *stateStore) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.papers))
+	for id, stored := range s.papers {
+		if !stored.deleted {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *stateStore) lastReconciled() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reconciledAt
+}
+
+// reconciled records the time of a finished reconciliation and persists it.
+func (s *stateStore) reconciled(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked([]stateLine{{LastReconciled: &t}})
+}
+
+// close syncs and closes the state file.
+func (s *stateStore) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package arxiv
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+)
+
+func TestStateStore_Compaction(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+
+	store, err := loadStateStore(path)
+	is.NoErr(err)
+
+	// every update appends a line, until most of them are outdated
+	for i := range stateCompactMinLines + 10 {
+		pos := opencdc.Position(strconv.Itoa(i))
+		store.stage(pos, paperState{Version: i, Hash: strconv.Itoa(i), id: "2401.00001"})
+		is.NoErr(store.commit(ctx, pos))
+	}
+	store.stage(opencdc.Position("d"), paperState{id: "2401.00001", deleted: true})
+	store.stage(opencdc.Position("n"), paperState{Version: 1, Hash: "new", id: "2401.00002"})
+	is.NoErr(store.commit(ctx, opencdc.Position("n")))
+	is.NoErr(store.close())
+
+	b, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.True(bytes.Count(b, []byte("\n")) < 20)
+
+	store, err = loadStateStore(path)
+	is.NoErr(err)
+	t.Cleanup(func() { _ = store.close() })
+	is.Equal(store.ids(), []string{"2401.00002"})
+	st, err := store.load("2401.00002")
+	is.NoErr(err)
+	is.Equal(st.Hash, "new")
+}
+
+func TestStateStore_TruncatedLine(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+
+	store, err := loadStateStore(path)
+	is.NoErr(err)
+	store.stage(opencdc.Position("1"), paperState{Version: 1, Hash: "a", id: "2401.00001"})
+	is.NoErr(store.commit(ctx, opencdc.Position("1")))
+	is.NoErr(store.close())
+
+	// a crash left half of a line behind
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	is.NoErr(err)
+	_, err = f.WriteString(`{"id":"2401.00002","state":{"ver`)
+	is.NoErr(err)
+	is.NoErr(f.Close())
+
+	store, err = loadStateStore(path)
+	is.NoErr(err)
+	store.stage(opencdc.Position("2"), paperState{Version: 1, Hash: "b", id: "2401.00003"})
+	is.NoErr(store.commit(ctx, opencdc.Position("2")))
+	is.NoErr(store.close())
+
+	store, err = loadStateStore(path)
+	is.NoErr(err)
+	t.Cleanup(func() { _ = store.close() })
+	is.Equal(store.ids(), []string{"2401.00001", "2401.00003"})
+	stored, ok := store.lookup("2401.00003")
+	is.True(ok)
+	is.Equal(stored.hash, "b")
+}
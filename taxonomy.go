@@ -0,0 +1,132 @@
+package arxiv
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed taxonomy.json
+var taxonomyJSON []byte
+
+// CategoryInfo describes an arXiv category and its place in the arXiv
+// category taxonomy (group → archive → category).
+type CategoryInfo struct {
+	// ID is the canonical category identifier (e.g. cs.LG).
+	ID          string
+	Name        string
+	Archive     string
+	ArchiveName string
+	Group       string
+	GroupName   string
+}
+
+type taxonomy struct {
+	categories map[string]CategoryInfo
+	archives   map[string]bool
+	aliases    map[string]string
+}
+
+var categoryTaxonomy = mustLoadTaxonomy(taxonomyJSON)
+
+func mustLoadTaxonomy(b []byte) *taxonomy {
+	var raw struct {
+		Groups []struct {
+			ID       string `json:"id"`
+			Name     string `json:"name"`
+			Archives []struct {
+				ID         string `json:"id"`
+				Name       string `json:"name"`
+				Categories []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"categories"`
+			} `json:"archives"`
+		} `json:"groups"`
+		Aliases map[string]string `json:"aliases"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		panic(fmt.Errorf("failed to parse embedded category taxonomy: %w", err))
+	}
+
+	t := &taxonomy{
+		categories: make(map[string]CategoryInfo),
+		archives:   make(map[string]bool),
+		aliases:    raw.Aliases,
+	}
+	for _, g := range raw.Groups {
+		for _, a := range g.Archives {
+			t.archives[a.ID] = true
+			for _, c := range a.Categories {
+				t.categories[c.ID] = CategoryInfo{
+					ID:          c.ID,
+					Name:        c.Name,
+					Archive:     a.ID,
+					ArchiveName: a.Name,
+					Group:       g.ID,
+					GroupName:   g.Name,
+				}
+			}
+		}
+	}
+	return t
+}
+
+// LookupCategory returns the taxonomy information for an arXiv category.
+// Legacy aliases (e.g. math.IT) resolve to their canonical category (cs.IT).
+func LookupCategory(term string) (CategoryInfo, bool) {
+	if canonical, ok := categoryTaxonomy.aliases[term]; ok {
+		term = canonical
+	}
+	info, ok := categoryTaxonomy.categories[term]
+	return info, ok
+}
+
+// categoryQueryRegex matches category filters in a search query, e.g.
+// cat:cs.LG, cat:"stat.ML" or cat:cs.*.
+var categoryQueryRegex = regexp.MustCompile(`\bcat:"?([A-Za-z0-9.\-*]+)"?`)
+
+// validateQueryCategories checks that all categories referenced by cat:
+// filters in the search query exist in the arXiv taxonomy.
+func validateQueryCategories(query string) error {
+	for _, m := range categoryQueryRegex.FindAllStringSubmatch(query, -1) {
+		term := m[1]
+		if validQueryCategory(term) {
+			continue
+		}
+		if suggestion := suggestCategory(term); suggestion != "" {
+			return fmt.Errorf("unknown arXiv category %q in search_query, did you mean %q?", term, suggestion)
+		}
+		return fmt.Errorf("unknown arXiv category %q in search_query", term)
+	}
+	return nil
+}
+
+func validQueryCategory(term string) bool {
+	if prefix, ok := strings.CutSuffix(term, ".*"); ok {
+		return categoryTaxonomy.archives[prefix]
+	}
+	if _, ok := LookupCategory(term); ok {
+		return true
+	}
+	_, ok := categoryTaxonomy.aliases[term]
+	return ok
+}
+
+// suggestCategory returns a known category that matches term when ignoring
+// case, or an empty string if there is none.
+func suggestCategory(term string) string {
+	for id := range categoryTaxonomy.categories {
+		if strings.EqualFold(id, term) {
+			return id
+		}
+	}
+	for alias := range categoryTaxonomy.aliases {
+		if strings.EqualFold(alias, term) {
+			return alias
+		}
+	}
+	return ""
+}
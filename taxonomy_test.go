@@ -0,0 +1,124 @@
+package arxiv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+)
+
+func TestLookupCategory(t *testing.T) {
+	tests := []struct {
+		term string
+		want arxiv.CategoryInfo
+		ok   bool
+	}{
+		{
+			term: "cs.LG",
+			want: arxiv.CategoryInfo{
+				ID: "cs.LG", Name: "Machine Learning",
+				Archive: "cs", ArchiveName: "Computer Science",
+				Group: "cs", GroupName: "Computer Science",
+			},
+			ok: true,
+		},
+		{
+			term: "math.IT",
+			want: arxiv.CategoryInfo{
+				ID: "cs.IT", Name: "Information Theory",
+				Archive: "cs", ArchiveName: "Computer Science",
+				Group: "cs", GroupName: "Computer Science",
+			},
+			ok: true,
+		},
+		{
+			term: "hep-th",
+			want: arxiv.CategoryInfo{
+				ID: "hep-th", Name: "High Energy Physics - Theory",
+				Archive: "hep-th", ArchiveName: "High Energy Physics - Theory",
+				Group: "physics", GroupName: "Physics",
+			},
+			ok: true,
+		},
+		{term: "cs.XX", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			is := is.New(t)
+			got, ok := arxiv.LookupCategory(tt.term)
+			is.Equal(ok, tt.ok)
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestSourceConfig_ValidateQueryCategories(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{query: "cat:cs.LG AND ti:transformer"},
+		{query: `cat:"stat.ML" OR cat:math.IT`},
+		{query: "cat:cs.* ANDNOT cat:astro-ph.CO"},
+		{query: "cat:cs.lg", wantErr: `unknown arXiv category "cs.lg" in search_query, did you mean "cs.LG"?`},
+		{query: "cat:foo.*", wantErr: `unknown arXiv category "foo.*" in search_query`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+			src := arxiv.NewSource()
+
+			err := sdk.Util.ParseConfig(ctx, map[string]string{
+				"search_query": tt.query,
+			}, src.Config(), arxiv.Connector.NewSpecification().SourceParams)
+			if tt.wantErr == "" {
+				is.NoErr(err)
+				return
+			}
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tt.wantErr))
+		})
+	}
+}
+
+func TestSource_CategoryEnrichment(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	response := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.12345v1</id>
+    <title>Sample Title</title>
+    <summary>Sample Summary</summary>
+    <published>2025-06-01T00:00:00Z</published>
+    <updated>2025-06-01T00:00:00Z</updated>
+    <arxiv:primary_category term="stat.ML" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="stat.ML" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.LG" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="I.2.6" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>`
+
+	server := createMockArxivServer(t, response)
+	defer server.Close()
+
+	src := openTestSource(ctx, t, server.URL, nil)
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(data["categories"], []string{"stat.ML", "cs.LG", "I.2.6"})
+	is.Equal(data["category_names"], []string{"Machine Learning", "Machine Learning", "I.2.6"})
+	is.Equal(data["archive"], "stat")
+	is.Equal(data["group"], "stat")
+}
@@ -0,0 +1,238 @@
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"golang.org/x/time/rate"
+)
+
+var errOAIRecordNotFound = errors.New("record not found in OAI-PMH repository")
+
+// VersionHistoryConfig configures fetching the version history and license
+// of every paper from the arXiv OAI-PMH interface (arXivRaw format).
+type VersionHistoryConfig struct {
+	// Enabled fetches the version history of every paper. This makes one
+	// additional request per paper.
+	Enabled bool `json:"enabled" default:"false"`
+	// URL is the URL of the OAI-PMH endpoint.
+	URL string `json:"url" default:"https://export.arxiv.org/oai2"`
+	// RequestInterval is the minimum time between two OAI-PMH requests.
+	RequestInterval time.Duration `json:"request_interval" default:"1s"`
+}
+
+func (c VersionHistoryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("version_history.url %q is not a valid URL", c.URL)
+	}
+	if c.RequestInterval < 0 {
+		return fmt.Errorf("version_history.request_interval must not be negative")
+	}
+	return nil
+}
+
+// Version is a single version of a paper.
+type Version struct {
+	Version   int       `json:"version"`
+	Submitted time.Time `json:"submitted"`
+	// Size is the size of the submission in bytes, 0 if unknown.
+	Size int64 `json:"size,omitempty"`
+}
+
+// versionFetcher fetches version histories from the OAI-PMH interface.
+type versionFetcher struct {
+	client  *http.Client
+	url     string
+	limiter *rate.Limiter
+	maxSize int64
+}
+
+func newVersionFetcher(client *http.Client, c VersionHistoryConfig, maxSize int64) *versionFetcher {
+	limit := rate.Inf
+	if c.RequestInterval > 0 {
+		limit = rate.Every(c.RequestInterval)
+	}
+	return &versionFetcher{
+		client:  client,
+		url:     c.URL,
+		limiter: rate.NewLimiter(limit, 1),
+		maxSize: maxSize,
+	}
+}
+
+// annotate sets the version history and license of the entry. Papers that
+// are not yet in the OAI-PMH repository (it is updated daily) are left
+// unchanged.
+func (f *versionFetcher) annotate(ctx context.Context, entry *ArxivEntry) error {
+	id, err := ParseIdentifier(entry.ID)
+	if err != nil {
+		return err
+	}
+	raw, err := f.fetch(ctx, id)
+	if errors.Is(err, errOAIRecordNotFound) {
+		sdk.Logger(ctx).Debug().Str("arxiv_id", id.Base()).Msg("no version history available yet")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	versions, err := raw.versions()
+	if err != nil {
+		return fmt.Errorf("invalid version history of %s: %w", id.Base(), err)
+	}
+	entry.Versions = versions
+	entry.License = strings.TrimSpace(raw.License)
+	entry.withdrawnVersion = raw.withdrawn()
+	return nil
+}
+
+type oaiResponse struct {
+	Error *struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	} `xml:"error"`
+	Raw arxivRaw `xml:"GetRecord>record>metadata>arXivRaw"`
+}
+
+type arxivRaw struct {
+	ID       string `xml:"id"`
+	Versions []struct {
+		Version string `xml:"version,attr"`
+		Date    string `xml:"date"`
+		Size    string `xml:"size"`
+		// SourceType is I (inactive) for versions withdrawing the paper.
+		SourceType string `xml:"source_type"`
+	} `xml:"version"`
+	Comments string `xml:"comments"`
+	License  string `xml:"license"`
+}
+
+func (f *versionFetcher) fetch(ctx context.Context, id Identifier) (arxivRaw, error) {
+	if err := f.limiter.Wait(ctx); err != nil {
+		return arxivRaw{}, err //nolint:wrapcheck // context errors are returned as is
+	}
+
+	params := url.Values{}
+	params.Set("verb", "GetRecord")
+	params.Set("identifier", "oai:arXiv.org:"+id.Base())
+	params.Set("metadataPrefix", "arXivRaw")
+
+	// a cached history could miss new versions and withdrawals
+	req, err := http.NewRequestWithContext(withRevalidation(ctx), http.MethodGet, f.url+"?"+params.Encode(), nil)
+	if err != nil {
+		return arxivRaw{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return arxivRaw{}, fmt.Errorf("failed to fetch version history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return arxivRaw{}, fmt.Errorf("arXiv OAI-PMH returned status %d: %s", resp.StatusCode, string(body))
+	}
+	body, err := readAllLimited(resp.Body, f.maxSize)
+	if err != nil {
+		return arxivRaw{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var r oaiResponse
+	if err := xml.Unmarshal(body, &r); err != nil {
+		return arxivRaw{}, fmt.Errorf("failed to parse OAI-PMH response: %w", err)
+	}
+	if r.Error != nil {
+		if r.Error.Code == "idDoesNotExist" {
+			return arxivRaw{}, fmt.Errorf("%w: %s", errOAIRecordNotFound, id.Base())
+		}
+		return arxivRaw{}, fmt.Errorf("arXiv OAI-PMH error %s: %s", r.Error.Code, strings.TrimSpace(r.Error.Message))
+	}
+	return r.Raw, nil
+}
+
+func (r arxivRaw) versions() ([]Version, error) {
+	versions := make([]Version, 0, len(r.Versions))
+	for _, v := range r.Versions {
+		n, err := strconv.Atoi(strings.TrimPrefix(v.Version, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", v.Version)
+		}
+		submitted, err := time.Parse("Mon, 2 Jan 2006 15:04:05 MST", strings.TrimSpace(v.Date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of version %q: %w", v.Version, err)
+		}
+		versions = append(versions, Version{
+			Version:   n,
+			Submitted: submitted.UTC(),
+			Size:      parseSubmissionSize(v.Size),
+		})
+	}
+	return versions, nil
+}
+
+// withdrawn reports whether the latest version withdrew the paper.
+func (r arxivRaw) withdrawn() bool {
+	if n := len(r.Versions); n > 0 && strings.TrimSpace(r.Versions[n-1].SourceType) == "I" {
+		return true
+	}
+	return withdrawnRegex.MatchString(r.Comments)
+}
+
+// parseSubmissionSize parses sizes like "37kb" or "1.2mb" into bytes. It returns 0 if
+// the size can't be parsed.
+func parseSubmissionSize(s string) int64 {
+	s = strings.ToLower(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30}, {"b", 1}} {
+		if trimmed, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = trimmed, unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return int64(n * float64(multiplier))
+}
+
+// versionsData returns the payload value of a version history, nil if the
+// history is unknown.
+func versionsData(versions []Version) interface{} {
+	if versions == nil {
+		return nil
+	}
+	out := make([]map[string]interface{}, len(versions))
+	for i, v := range versions {
+		out[i] = map[string]interface{}{
+			"version":   v.Version,
+			"submitted": v.Submitted.Format(time.RFC3339Nano),
+			"size":      nullableInt64(v.Size),
+		}
+	}
+	return out
+}
+
+func nullableInt64(v int64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,131 @@
+package arxiv_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	arxiv "github.com/raulb/conduit-connector-arxiv"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func newVersionHistoryServer(t *testing.T) *arxivtest.Server {
+	server := arxivtest.NewServer([]arxivtest.Paper{
+		{
+			ID:         "2401.00001",
+			Version:    2,
+			Published:  time.Date(2024, 1, 2, 19, 18, 42, 0, time.UTC),
+			Updated:    time.Date(2024, 7, 24, 20, 10, 27, 0, time.UTC),
+			Categories: []string{"cs.LG"},
+			Versions: []arxivtest.Version{
+				{Submitted: time.Date(2024, 1, 2, 19, 18, 42, 0, time.UTC), Size: "37kb"},
+				{Submitted: time.Date(2024, 7, 24, 20, 10, 27, 0, time.UTC), Size: "1.2mb"},
+			},
+			License: "http://creativecommons.org/licenses/by/4.0/",
+		},
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSource_VersionHistory(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := newVersionHistoryServer(t)
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":                     "cat:cs.LG",
+		"version_history.enabled":          "true",
+		"version_history.url":              server.OAIURL(),
+		"version_history.request_interval": "0s",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data, ok := rec.Payload.After.(opencdc.StructuredData)
+	is.True(ok)
+	is.Equal(data["license"], "http://creativecommons.org/licenses/by/4.0/")
+	is.Equal(data["versions"], []map[string]interface{}{
+		{"version": 1, "submitted": "2024-01-02T19:18:42Z", "size": int64(37 * 1024)},
+		{"version": 2, "submitted": "2024-07-24T20:10:27Z", "size": int64(1258291)},
+	})
+
+	requests := server.Requests()
+	is.Equal(len(requests), 2)
+	is.Equal(requests[1].Get("identifier"), "oai:arXiv.org:2401.00001")
+}
+
+func TestSource_VersionHistoryTyped(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := newVersionHistoryServer(t)
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":                       "cat:cs.LG",
+		"version_history.enabled":            "true",
+		"version_history.url":                server.OAIURL(),
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	out := decodeTypedPayload(ctx, t, rec)
+	versions := out["versions"].([]interface{})
+	is.Equal(len(versions), 2)
+	v1 := versions[0].(map[string]interface{})
+	is.Equal(v1["version"], 1)
+	is.Equal(v1["submitted"].(time.Time).UTC(), time.Date(2024, 1, 2, 19, 18, 42, 0, time.UTC))
+	is.Equal(v1["size"], int64(37*1024))
+}
+
+func TestSource_VersionHistoryDisabled(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := newVersionHistoryServer(t)
+	src := openTestSource(ctx, t, server.URL(), map[string]string{"search_query": "cat:cs.LG"})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+
+	data := rec.Payload.After.(opencdc.StructuredData)
+	is.Equal(data["versions"], nil)
+	is.Equal(data["license"], nil)
+	is.Equal(len(server.Requests()), 1)
+}
+
+func TestSource_VersionHistoryUnavailable(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := newVersionHistoryServer(t)
+	// the OAI-PMH request fails, the record is still produced
+	server.InjectFaults(arxivtest.Fault{}, arxivtest.Fault{Status: http.StatusServiceUnavailable})
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":            "cat:cs.LG",
+		"version_history.enabled": "true",
+		"version_history.url":     server.OAIURL(),
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(rec.Payload.After.(opencdc.StructuredData)["versions"], nil)
+}
+
+func TestVersionHistoryConfig_Validate(t *testing.T) {
+	is := is.New(t)
+
+	err := configureTestSource(context.Background(), arxiv.NewSource(), "http://localhost", map[string]string{
+		"version_history.enabled": "true",
+		"version_history.url":     "not a url",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `version_history.url "not a url" is not a valid URL`))
+}
@@ -0,0 +1,42 @@
+package arxiv
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// WithdrawnModeDelete emits withdrawn papers as delete records keyed on
+	// the versionless identifier.
+	WithdrawnModeDelete = "delete"
+	// WithdrawnModeFlag emits withdrawn papers as regular records with the
+	// withdrawn payload field set.
+	WithdrawnModeFlag = "flag"
+)
+
+// withdrawnRegex matches the notices arXiv and authors put in the comment
+// and abstract of a withdrawn version.
+var withdrawnRegex = regexp.MustCompile(`(?i)(?:\b(?:paper|article|submission|manuscript|preprint|work)\s+(?:has\s+been|is|was|is\s+being)\s+withdrawn\b` +
+	`|\bwithdrawn\s+by\s+(?:the\s+)?(?:authors?|arxiv|admin(?:istrators?)?|submitter)\b` +
+	`|\bwithdrawn\s+due\s+to\b` +
+	`|^\s*withdrawn\W*$)`)
+
+// maxWithdrawnAbstractLength is the length up to which an abstract mentioning
+// the withdrawal is taken to be a withdrawal notice. Longer abstracts are
+// regular abstracts that happen to contain the phrase.
+const maxWithdrawnAbstractLength = 500
+
+// isWithdrawn reports whether the latest version of an entry withdraws the
+// paper, based on its comment and abstract, or the version history if it was
+// fetched.
+func isWithdrawn(e *ArxivEntry) bool {
+	if withdrawnRegex.MatchString(e.Comment) {
+		return true
+	}
+	abstract := strings.TrimSpace(e.Summary)
+	if utf8.RuneCountInString(abstract) <= maxWithdrawnAbstractLength && withdrawnRegex.MatchString(abstract) {
+		return true
+	}
+	return e.withdrawnVersion
+}
@@ -0,0 +1,207 @@
+package arxiv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	"github.com/raulb/conduit-connector-arxiv/arxivtest"
+)
+
+func withdrawnCorpus() []arxivtest.Paper {
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []arxivtest.Paper{
+		{
+			ID:         "2401.00001",
+			Version:    2,
+			Summary:    "This paper has been withdrawn by the author due to a crucial error in equation 3.",
+			Comment:    "This paper has been withdrawn",
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+		{
+			ID:         "2401.00002",
+			Summary:    "A regular abstract that is long enough to pass the filter.",
+			Comment:    "10 pages",
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+		{
+			ID:         "hep-th/9901001",
+			Version:    3,
+			Summary:    "withdrawn",
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+		{
+			ID: "2401.00004",
+			// long abstracts only mention withdrawals in passing
+			Summary:    "We study why papers are withdrawn by the authors. " + strings.Repeat("More text. ", 60),
+			Published:  published,
+			Categories: []string{"cs.LG"},
+		},
+	}
+}
+
+func readAll(ctx context.Context, t *testing.T, src sdk.Source) []opencdc.Record {
+	t.Helper()
+	var recs []opencdc.Record
+	for {
+		rec, err := src.Read(ctx)
+		if err == sdk.ErrBackoffRetry {
+			return recs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestSource_WithdrawnAsDelete(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(withdrawnCorpus())
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query": "cat:cs.LG",
+		"sort_by":      "relevance",
+		// the withdrawal notices don't pass the filter, the deletes must
+		// still be emitted
+		"filter.min_abstract_length":         "50",
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	recs := readAll(ctx, t, src)
+	is.Equal(len(recs), 4)
+
+	is.Equal(recs[0].Operation, opencdc.OperationDelete)
+	is.Equal(recs[0].Key, opencdc.RawData("2401.00001"))
+	is.Equal(recs[0].Payload.After, nil)
+	is.Equal(recs[0].Metadata["arxiv.withdrawn"], "true")
+	is.Equal(recs[0].Metadata["arxiv.version"], "2")
+
+	is.Equal(recs[1].Operation, opencdc.OperationCreate)
+	is.Equal(recs[1].Key, opencdc.RawData("2401.00002"))
+	_, ok := recs[1].Metadata["arxiv.withdrawn"]
+	is.True(!ok)
+
+	is.Equal(recs[2].Operation, opencdc.OperationDelete)
+	is.Equal(recs[2].Key, opencdc.RawData("hep-th/9901001"))
+
+	is.Equal(recs[3].Operation, opencdc.OperationCreate)
+}
+
+func TestSource_WithdrawnDeleteKey(t *testing.T) {
+	ctx := context.Background()
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the default key and a structured one
+	for _, key := range []string{"arxiv_base_id", "arxiv_base_id,published"} {
+		t.Run(key, func(t *testing.T) {
+			is := is.New(t)
+
+			paper := arxivtest.Paper{
+				ID:         "2401.00001",
+				Summary:    "A regular abstract.",
+				Published:  published,
+				Categories: []string{"cs.LG"},
+			}
+			server := arxivtest.NewServer([]arxivtest.Paper{paper})
+			t.Cleanup(server.Close)
+
+			cfg := map[string]string{
+				"search_query":      "cat:cs.LG",
+				"field_mapping.key": key,
+			}
+
+			created := readAll(ctx, t, openTestSource(ctx, t, server.URL(), cfg))
+			is.Equal(len(created), 1)
+			is.Equal(created[0].Operation, opencdc.OperationCreate)
+
+			paper.Version = 2
+			paper.Summary = "This paper has been withdrawn."
+			server.Add(paper)
+
+			deleted := readAll(ctx, t, openTestSource(ctx, t, server.URL(), cfg))
+			is.Equal(len(deleted), 1)
+			is.Equal(deleted[0].Operation, opencdc.OperationDelete)
+			is.Equal(deleted[0].Key, created[0].Key)
+		})
+	}
+}
+
+func TestSource_WithdrawnAsFlag(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	server := arxivtest.NewServer(withdrawnCorpus())
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":                       "cat:cs.LG",
+		"sort_by":                            "relevance",
+		"withdrawn_mode":                     "flag",
+		"sdk.schema.extract.payload.enabled": "true",
+	})
+
+	recs := readAll(ctx, t, src)
+	is.Equal(len(recs), 4)
+
+	var withdrawn []interface{}
+	for _, rec := range recs {
+		is.Equal(rec.Operation, opencdc.OperationCreate)
+		withdrawn = append(withdrawn, decodeTypedPayload(ctx, t, rec)["withdrawn"])
+	}
+	is.Equal(withdrawn, []interface{}{true, false, true, false})
+	is.Equal(recs[0].Key, opencdc.RawData("2401.00001"))
+}
+
+func TestSource_WithdrawnFromVersionHistory(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := arxivtest.NewServer([]arxivtest.Paper{{
+		ID:         "2401.00001",
+		Version:    2,
+		Summary:    "An abstract that wasn't replaced.",
+		Published:  published,
+		Categories: []string{"cs.LG"},
+		Versions: []arxivtest.Version{
+			{Submitted: published, Size: "120kb"},
+			{Submitted: published.Add(time.Hour), Size: "1kb", Withdrawn: true},
+		},
+	}})
+	t.Cleanup(server.Close)
+
+	src := openTestSource(ctx, t, server.URL(), map[string]string{
+		"search_query":            "cat:cs.LG",
+		"version_history.enabled": "true",
+		"version_history.url":     server.OAIURL(),
+		// the withdrawal is only known from the version history, the delete
+		// must still bypass the filters
+		"filter.min_abstract_length": "50",
+	})
+
+	rec, err := src.Read(ctx)
+	is.NoErr(err)
+	is.Equal(rec.Operation, opencdc.OperationDelete)
+	is.Equal(rec.Key, opencdc.RawData("2401.00001"))
+}
+
+func TestSourceConfig_InvalidWithdrawnMode(t *testing.T) {
+	is := is.New(t)
+
+	err := openTestSourceErr(context.Background(), t, "http://localhost", map[string]string{
+		"withdrawn_mode": "ignore",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "withdrawn_mode must be either delete or flag"))
+}